@@ -0,0 +1,173 @@
+package accesslog
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SKF/go-utility/v2/log"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	"github.com/gorilla/mux"
+	otel_trace "go.opentelemetry.io/otel/trace"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/client-id/models"
+	"github.com/SKF/go-enlight-middleware/internal/identity"
+	"github.com/SKF/go-enlight-middleware/internal/response"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+// Redacted replaces the values of redacted query parameters.
+const Redacted = "REDACTED"
+
+type Middleware struct {
+	Logger log.Logger
+
+	sampleRate              float64
+	ignoredRoutes           route.Any
+	redactedQueryParameters []string
+}
+
+// New returns a new access log middleware, logging one line per request. The
+// middleware should be added right after the recovery and request id
+// middlewares, to also log the requests rejected by the other middlewares. The
+// user and client ID resolved by the authentication and client id middlewares
+// are logged regardless of their order.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		Logger: log.Base(),
+
+		sampleRate:    1,
+		ignoredRoutes: route.Any{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *Middleware) IgnoreRoute(r *mux.Route) *Middleware {
+	return m.IgnoreMatching(route.Mux(r))
+}
+
+// IgnoreMatching disables logging of all requests matched by matcher, e.g. health checks.
+func (m *Middleware) IgnoreMatching(matcher route.Matcher) *Middleware {
+	m.ignoredRoutes = append(m.ignoredRoutes, matcher)
+	return m
+}
+
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageAccessLog
+}
+
+func (m *Middleware) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if m.ignoredRoutes.Match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, holder := identity.NewContext(r.Context())
+			r = r.WithContext(ctx)
+
+			start := time.Now()
+			rw := response.NewWriter(w)
+			completed := false
+
+			// Deferred to also log requests panicking, which are answered by the
+			// recovery middleware wrapping this middleware.
+			defer func() {
+				m.log(r, holder, rw, time.Since(start), completed)
+			}()
+
+			next.ServeHTTP(rw, r)
+
+			completed = true
+		})
+	}
+}
+
+func (m *Middleware) log(r *http.Request, holder *identity.Holder, w *response.Writer, duration time.Duration, completed bool) {
+	status := w.Status()
+
+	switch {
+	case status != 0:
+	case completed:
+		status = http.StatusOK
+	default:
+		status = http.StatusInternalServerError
+	}
+
+	// Server errors are always logged, other requests are sampled.
+	if status < http.StatusInternalServerError && m.sampleRate < 1 && rand.Float64() >= m.sampleRate { //nolint:gosec
+		return
+	}
+
+	ctx := r.Context()
+
+	l := m.Logger.
+		WithTracing(ctx).
+		WithField("http.method", r.Method).
+		WithField("http.url", m.redactedURL(r.URL)).
+		WithField("http.status_code", status).
+		WithField("http.response_size", w.BytesWritten()).
+		WithField("duration", duration.Nanoseconds())
+
+	if template, found := route.Resolve(r); found {
+		l = l.WithField("http.route", template)
+	}
+
+	if userID := holder.UserID(); userID != "" {
+		l = l.WithField("userId", userID)
+	} else if userID, ok := useridcontext.FromContext(ctx); ok {
+		l = l.WithField("userId", userID)
+	}
+
+	if clientID, clientName := holder.Client(); clientID != "" {
+		l = l.WithField("clientId", clientID).WithField("clientName", clientName)
+	} else if clientID, ok := models.FromContext(ctx); ok {
+		l = l.WithField("clientId", clientID.Identifier.String()).WithField("clientName", clientID.Name)
+	}
+
+	if spanContext := otel_trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		l = l.WithField("trace_id", spanContext.TraceID().String()).WithField("span_id", spanContext.SpanID().String())
+	}
+
+	l.Info("HTTP request handled")
+}
+
+// redactedURL returns the URL with the values of the redacted query parameters
+// replaced, keeping the order of the parameters.
+func (m *Middleware) redactedURL(u *url.URL) string {
+	if len(m.redactedQueryParameters) == 0 || u.RawQuery == "" {
+		return u.RequestURI()
+	}
+
+	parameters := strings.Split(u.RawQuery, "&")
+
+	for i, parameter := range parameters {
+		key, _, _ := strings.Cut(parameter, "=")
+
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if slices.ContainsFunc(m.redactedQueryParameters, func(redacted string) bool {
+			return strings.EqualFold(redacted, key)
+		}) {
+			parameters[i] = url.QueryEscape(key) + "=" + Redacted
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = strings.Join(parameters, "&")
+
+	return redacted.RequestURI()
+}
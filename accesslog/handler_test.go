@@ -0,0 +1,218 @@
+package accesslog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SKF/go-utility/v2/log"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	"github.com/SKF/go-utility/v2/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/accesslog"
+	"github.com/SKF/go-enlight-middleware/authentication"
+	clientid "github.com/SKF/go-enlight-middleware/client-id"
+	"github.com/SKF/go-enlight-middleware/client-id/models"
+	"github.com/SKF/go-enlight-middleware/client-id/store"
+	"github.com/SKF/go-enlight-middleware/recovery"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+type entry struct {
+	message string
+	fields  map[string]any
+}
+
+type recordingLogger struct {
+	log.Logger
+
+	fields  map[string]any
+	entries *[]entry
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{fields: map[string]any{}, entries: &[]entry{}}
+}
+
+func (l *recordingLogger) WithField(key string, value any) log.Logger {
+	fields := map[string]any{key: value}
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+
+	return &recordingLogger{fields: fields, entries: l.entries}
+}
+
+func (l *recordingLogger) WithTracing(context.Context) log.Logger {
+	return l
+}
+
+func (l *recordingLogger) Info(args ...any) {
+	*l.entries = append(*l.entries, entry{message: args[0].(string), fields: l.fields})
+}
+
+func TestMiddleware(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New(accesslog.WithRedactedQueryParameters("token"))
+	m.Logger = logger
+
+	router := mux.NewRouter()
+	router.Use(m.Middleware())
+	router.Path("/nodes/{nodeId}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello")) //nolint:errcheck
+	})
+
+	clientID := models.ClientID{Identifier: uuid.New(), Name: "client"}
+
+	ctx := useridcontext.NewContext(context.Background(), "user")
+	ctx = clientID.EmbedIntoContext(ctx)
+
+	req := httptest.NewRequest(http.MethodPost, "/nodes/123?token=secret&limit=10", nil).WithContext(ctx)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, *logger.entries, 1)
+
+	fields := (*logger.entries)[0].fields
+	require.Equal(t, http.MethodPost, fields["http.method"])
+	require.Equal(t, "/nodes/123?token=REDACTED&limit=10", fields["http.url"])
+	require.Equal(t, "/nodes/{nodeId}", fields["http.route"])
+	require.Equal(t, http.StatusCreated, fields["http.status_code"])
+	require.EqualValues(t, 5, fields["http.response_size"])
+	require.Contains(t, fields, "duration")
+	require.Equal(t, "user", fields["userId"])
+	require.Equal(t, clientID.Identifier.String(), fields["clientId"])
+	require.Equal(t, "client", fields["clientName"])
+}
+
+func TestMiddleware_ImplicitStatus(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New()
+	m.Logger = logger
+
+	handler := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Len(t, *logger.entries, 1)
+	require.Equal(t, http.StatusOK, (*logger.entries)[0].fields["http.status_code"])
+	require.NotContains(t, (*logger.entries)[0].fields, "userId")
+}
+
+func TestMiddleware_IdentityOfInnerMiddlewares(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New()
+	m.Logger = logger
+
+	clientID := models.ClientID{Identifier: uuid.New(), Name: "client", Environments: models.Environments{"prod"}}
+
+	clientIDMiddleware := clientid.New(
+		clientid.WithStore(store.NewLocal().Add(clientID)),
+		clientid.WithHeaderExtractor("X-Client-ID"),
+	)
+
+	handler := m.Middleware()(clientIDMiddleware.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Client-ID", clientID.Identifier.String())
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, *logger.entries, 1)
+	require.Equal(t, clientID.Identifier.String(), (*logger.entries)[0].fields["clientId"])
+	require.Equal(t, "client", (*logger.entries)[0].fields["clientName"])
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New()
+	m.Logger = logger
+
+	handler := recovery.New(recovery.WithPanicLogger(nil)).Middleware()(m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Len(t, *logger.entries, 1)
+	require.Equal(t, http.StatusInternalServerError, (*logger.entries)[0].fields["http.status_code"])
+}
+
+func TestMiddleware_LogsRejectionsInChain(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New()
+	m.Logger = logger
+
+	authn := authentication.New(authentication.WithKeySetURL(""))
+	defer authn.Stop()
+
+	chain := middleware.MustChain(authn, m, recovery.New())
+
+	w := httptest.NewRecorder()
+	chain(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Len(t, *logger.entries, 1)
+	require.Equal(t, http.StatusUnauthorized, (*logger.entries)[0].fields["http.status_code"])
+}
+
+func TestMiddleware_IgnoreMatching(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New().IgnoreMatching(route.Path("/health"))
+	m.Logger = logger
+
+	handler := m.Middleware()(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Empty(t, *logger.entries)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes", nil))
+	require.Len(t, *logger.entries, 1)
+}
+
+func TestMiddleware_SampleRate(t *testing.T) {
+	logger := newRecordingLogger()
+	m := accesslog.New(accesslog.WithSampleRate(0))
+	m.Logger = logger
+
+	status := http.StatusOK
+	handler := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Empty(t, *logger.entries, "sampled out")
+
+	status = http.StatusInternalServerError
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Len(t, *logger.entries, 1, "server errors are always logged")
+}
+
+func TestMiddleware_RedactedQueryParameters(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "/nodes", expected: "/nodes"},
+		{url: "/nodes?limit=10", expected: "/nodes?limit=10"},
+		{url: "/nodes?Token=a&api_key=b&token", expected: "/nodes?Token=REDACTED&api_key=REDACTED&token=REDACTED"},
+		{url: "/nodes?a=1&token=x&b=2", expected: "/nodes?a=1&token=REDACTED&b=2"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.url, func(t *testing.T) {
+			logger := newRecordingLogger()
+			m := accesslog.New(accesslog.WithRedactedQueryParameters("token", "API_KEY"))
+			m.Logger = logger
+
+			m.Middleware()(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tC.url, nil))
+
+			require.Len(t, *logger.entries, 1)
+			require.Equal(t, tC.expected, (*logger.entries)[0].fields["http.url"])
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package accesslog
+
+type Option func(*Middleware)
+
+// WithSampleRate only logs the fraction, between 0 and 1, of the requests.
+// Requests resulting in server errors are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(m *Middleware) {
+		m.sampleRate = rate
+	}
+}
+
+// WithRedactedQueryParameters replaces the values of the query parameters,
+// matched case-insensitively, e.g. "token" or "api_key".
+func WithRedactedQueryParameters(parameters ...string) Option {
+	return func(m *Middleware) {
+		m.redactedQueryParameters = append(m.redactedQueryParameters, parameters...)
+	}
+}
@@ -0,0 +1,161 @@
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwt"
+	jwt_go "github.com/golang-jwt/jwt/v5"
+)
+
+type TokenUse string
+
+const (
+	TokenUseID     TokenUse = jwt.TokenUseID
+	TokenUseAccess TokenUse = jwt.TokenUseAccess
+)
+
+// Claims is a read-only view of the claims of a verified token, retrieved
+// through ClaimsFromContext.
+type Claims struct {
+	claims   jwt.Claims
+	rawToken string
+	payload  map[string]any
+}
+
+func NewClaims(claims jwt.Claims, rawToken string) Claims {
+	claims.Audience = slices.Clone(claims.Audience)
+	claims.CognitoGroups = slices.Clone(claims.CognitoGroups)
+
+	return Claims{
+		claims:   claims,
+		rawToken: rawToken,
+		payload:  decodePayload(claims, rawToken),
+	}
+}
+
+// decodePayload decodes all claims of the raw token, which has already been
+// verified. If the raw token can't be decoded only the claims known by
+// jwt.Claims are available.
+func decodePayload(claims jwt.Claims, rawToken string) map[string]any {
+	payload := jwt_go.MapClaims{}
+	if _, _, err := jwt_go.NewParser().ParseUnverified(rawToken, payload); err == nil {
+		return payload
+	}
+
+	encoded, err := json.Marshal(claims)
+	if err != nil {
+		return nil
+	}
+
+	payload = jwt_go.MapClaims{}
+	if err := json.Unmarshal(encoded, &payload); err != nil {
+		return nil
+	}
+
+	return payload
+}
+
+type claimsContextKey struct{}
+
+func (c Claims) EmbedIntoContext(parent context.Context) context.Context {
+	return context.WithValue(parent, claimsContextKey{}, c)
+}
+
+// ClaimsFromContext returns the claims of the token verified by the authentication middleware.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
+
+// RawToken is the token as provided in the request, without any "Bearer" prefix.
+func (c Claims) RawToken() string {
+	return c.rawToken
+}
+
+func (c Claims) TokenUse() TokenUse {
+	return TokenUse(c.claims.TokenUse)
+}
+
+func (c Claims) Subject() string {
+	return c.claims.Subject
+}
+
+func (c Claims) Issuer() string {
+	return c.claims.Issuer
+}
+
+func (c Claims) Audience() []string {
+	return slices.Clone(c.claims.Audience)
+}
+
+func (c Claims) ExpiresAt() time.Time {
+	return numericDate(c.claims.ExpiresAt)
+}
+
+func (c Claims) IssuedAt() time.Time {
+	return numericDate(c.claims.IssuedAt)
+}
+
+func (c Claims) Username() string {
+	return c.claims.Username
+}
+
+func (c Claims) CognitoGroups() []string {
+	return slices.Clone(c.claims.CognitoGroups)
+}
+
+// UserID is the Enlight User ID of the authenticated, or impersonated, user.
+func (c Claims) UserID() string {
+	userID, _ := resolveUserAndAuthor(c.claims)
+	return userID
+}
+
+// AuthorID is the Enlight User ID of the user who created the token.
+func (c Claims) AuthorID() string {
+	_, authorID := resolveUserAndAuthor(c.claims)
+	return authorID
+}
+
+func (c Claims) EnlightCompanyID() string {
+	return c.claims.EnlightCompanyID
+}
+
+func (c Claims) EnlightAccess() string {
+	return c.claims.EnlightAccess
+}
+
+func (c Claims) EnlightEmail() string {
+	return c.claims.EnlightEmail
+}
+
+// EnlightRoles returns the comma separated roles of the "enlightRoles" claim.
+func (c Claims) EnlightRoles() []string {
+	var roles []string
+
+	for _, role := range strings.Split(c.claims.EnlightRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// Claim returns the value of the named claim, e.g. "enlightCompanyId",
+// "cognito:groups" or "email", as decoded from JSON.
+func (c Claims) Claim(name string) (any, bool) {
+	value, found := c.payload[name]
+	return value, found
+}
+
+func numericDate(date *jwt_go.NumericDate) time.Time {
+	if date == nil {
+		return time.Time{}
+	}
+
+	return date.Time
+}
@@ -0,0 +1,54 @@
+package authentication
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/grpcstatus"
+	"github.com/SKF/go-enlight-middleware/internal/grpcutil"
+)
+
+// UnaryServerInterceptor authenticates gRPC calls using the bearer token of the
+// "authorization" metadata. Calls are matched by IgnoreMatching using their full
+// method name as path, e.g. route.Path("/grpc.health.v1.Health/Check").
+func (m *Middleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	m.startVerifier()
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := m.intercept(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates gRPC streams, see UnaryServerInterceptor.
+func (m *Middleware) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	m.startVerifier()
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := m.intercept(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, grpcutil.WithContext(ss, ctx))
+	}
+}
+
+func (m *Middleware) intercept(ctx context.Context, fullMethod string) (context.Context, error) {
+	spanCtx, span := m.Tracer.StartSpan(ctx, "Authentication")
+	defer span.End()
+
+	r, err := m.authenticate(spanCtx, grpcutil.Request(ctx, fullMethod))
+	if err != nil {
+		middleware.RecordProblem(span, err)
+		return nil, grpcstatus.Error(err)
+	}
+
+	return r.Context(), nil
+}
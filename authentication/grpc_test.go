@@ -0,0 +1,120 @@
+package authentication_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwt"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/SKF/go-enlight-middleware/authentication"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	key, set := createKey(t)
+	otherKey, _ := createKey(t)
+
+	s := createJWKSServer(t, set)
+	defer s.Close()
+
+	userID := uuid.New().String()
+	claims := map[string]any{
+		"token_use":      jwt.TokenUseAccess,
+		"username":       "a.b@example.com",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"cognito:groups": []string{fmt.Sprintf("enlightUserId:%s", userID)},
+	}
+
+	interceptor := authentication.New(authentication.WithKeySetURL(s.URL)).
+		IgnoreMatching(route.Path("/grpc.health.v1.Health/Check")).
+		UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		actual, _ := useridcontext.FromContext(ctx)
+		return actual, nil
+	}
+
+	testCases := []struct {
+		desc          string
+		method        string
+		authorization string
+		expected      codes.Code
+	}{
+		{desc: "valid token", method: "/nodes.Nodes/GetNode", authorization: "Bearer " + string(createSignedToken(t, key, claims)), expected: codes.OK},
+		{desc: "token without prefix", method: "/nodes.Nodes/GetNode", authorization: string(createSignedToken(t, key, claims)), expected: codes.OK},
+		{desc: "missing token", method: "/nodes.Nodes/GetNode", expected: codes.Unauthenticated},
+		{desc: "unverifiable token", method: "/nodes.Nodes/GetNode", authorization: string(createSignedToken(t, otherKey, claims)), expected: codes.Unauthenticated},
+		{desc: "ignored method", method: "/grpc.health.v1.Health/Check", expected: codes.OK},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if tC.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tC.authorization))
+			}
+
+			response, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tC.method}, handler)
+			require.Equal(t, tC.expected, status.Code(err), err)
+
+			if tC.expected == codes.OK && tC.authorization != "" {
+				assert.Equal(t, userID, response)
+			}
+		})
+	}
+}
+
+func Test_StreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	key, set := createKey(t)
+
+	s := createJWKSServer(t, set)
+	defer s.Close()
+
+	token := createSignedToken(t, key, map[string]any{
+		"token_use": jwt.TokenUseAccess,
+		"username":  "a.b@example.com",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	})
+
+	interceptor := authentication.New(authentication.WithKeySetURL(s.URL)).StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/nodes.Nodes/WatchNodes"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+string(token)))
+
+	err := interceptor(nil, serverStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		claims, ok := authentication.ClaimsFromContext(stream.Context())
+		require.True(t, ok)
+		assert.Equal(t, "a.b@example.com", claims.Username())
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = interceptor(nil, serverStream{ctx: context.Background()}, info, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -5,13 +5,12 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/SKF/go-rest-utility/problems"
 	"github.com/SKF/go-utility/v2/accesstokensubcontext"
 	"github.com/SKF/go-utility/v2/impersonatercontext"
-	"github.com/SKF/go-utility/v2/jwk"
 	"github.com/SKF/go-utility/v2/jwt"
-	"github.com/SKF/go-utility/v2/log"
 	"github.com/SKF/go-utility/v2/stages"
 	"github.com/SKF/go-utility/v2/useridcontext"
 	jwt_go "github.com/golang-jwt/jwt/v5"
@@ -20,30 +19,36 @@ import (
 
 	middleware "github.com/SKF/go-enlight-middleware"
 	custom_problems "github.com/SKF/go-enlight-middleware/authentication/problems"
+	"github.com/SKF/go-enlight-middleware/internal/identity"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 const (
+	metricsName = "authentication"
+
 	userIDPrefix   = "enlightUserId:"
 	authorIDPrefix = "authorId:"
 )
 
 type Middleware struct {
 	TokenExtractor jwt_request.Extractor
+	TokenVerifier  TokenVerifier
 	Tracer         middleware.Tracer
+	Metrics        middleware.Metrics
 
-	unauthenticatedRoutes []*mux.Route
+	unauthenticatedRoutes route.Any
+	claimsValidation      claimsValidation
 }
 
 func New(opts ...Option) *Middleware {
-	defaultStage := stages.StageProd
-
-	jwk.Configure(jwk.Config{Stage: defaultStage})
-
 	m := &Middleware{
 		TokenExtractor: jwt_request.AuthorizationHeaderExtractor,
+		TokenVerifier:  NewJWKSVerifier(KeySetURL(stages.StageProd)),
 		Tracer:         middleware.DefaultTracer,
+		Metrics:        middleware.DefaultMetrics,
 
-		unauthenticatedRoutes: []*mux.Route{},
+		unauthenticatedRoutes: route.Any{},
 	}
 
 	for _, opt := range opts {
@@ -53,57 +58,96 @@ func New(opts ...Option) *Middleware {
 	return m
 }
 
-func (m *Middleware) IgnoreRoute(route *mux.Route) *Middleware {
-	m.unauthenticatedRoutes = append(m.unauthenticatedRoutes, route)
+func (m *Middleware) IgnoreRoute(r *mux.Route) *Middleware {
+	return m.IgnoreMatching(route.Mux(r))
+}
+
+// IgnoreMatching disables authentication for all requests matched by matcher.
+func (m *Middleware) IgnoreMatching(matcher route.Matcher) *Middleware {
+	m.unauthenticatedRoutes = append(m.unauthenticatedRoutes, matcher)
 	return m
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageAuthentication
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
-	if err := jwk.RefreshKeySets(); err != nil {
-		log.WithError(err).Error("Unable to refresh JWKS in AuthenticationMiddleware")
-	}
+	m.startVerifier()
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, span := m.Tracer.StartSpan(r.Context(), "Authentication")
 
-			if m.isAuthenticationNeeded(ctx, r) {
-				token, err := m.parseFromRequest(ctx, r)
-				if err != nil {
-					problems.WriteResponse(ctx, err, w, r)
-					span.End()
-
-					return
-				}
+			authenticated, err := m.authenticate(ctx, r)
+			if err != nil {
+				middleware.RecordProblem(span, err)
+				problems.WriteResponse(ctx, err, w, r)
+				span.End()
 
-				r, err = m.decorateValidRequest(ctx, r, token)
-				if err != nil {
-					problems.WriteResponse(ctx, err, w, r)
-					span.End()
-
-					return
-				}
+				return
 			}
 
 			span.End()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, authenticated)
 		})
 	}
 }
 
+func (m *Middleware) startVerifier() {
+	if m.TokenVerifier == nil {
+		m.TokenVerifier = NewJWKSVerifier(KeySetURL(stages.StageProd))
+	}
+
+	if verifier, ok := m.TokenVerifier.(backgroundVerifier); ok {
+		verifier.Start(context.Background())
+	}
+}
+
+// authenticate verifies the token of the request, unless authentication is not
+// needed, and returns the request decorated with the identity of the user.
+func (m *Middleware) authenticate(ctx context.Context, r *http.Request) (*http.Request, error) {
+	if !m.isAuthenticationNeeded(ctx, r) {
+		metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeSkipped)
+		return r, nil
+	}
+
+	token, err := m.parseFromRequest(ctx, r)
+	if err != nil {
+		metrics.CountRequest(m.Metrics, metricsName, r, err)
+		return nil, err
+	}
+
+	authenticated, err := m.decorateValidRequest(ctx, r, token)
+	metrics.CountRequest(m.Metrics, metricsName, r, err)
+
+	return authenticated, err
+}
+
+// Stop ends the background work of the token verifier, e.g. the refresh of the
+// JWKS started by Middleware and the interceptors.
+func (m *Middleware) Stop() {
+	if verifier, ok := m.TokenVerifier.(backgroundVerifier); ok {
+		verifier.Stop()
+	}
+}
+
+// Ready returns an error until the token verifier is able to verify tokens,
+// e.g. before the JWKS has been loaded. Intended to be used by health endpoints.
+func (m *Middleware) Ready() error {
+	if verifier, ok := m.TokenVerifier.(backgroundVerifier); ok {
+		return verifier.Ready()
+	}
+
+	return nil
+}
+
 func (m *Middleware) isAuthenticationNeeded(ctx context.Context, r *http.Request) bool {
 	_, span := m.Tracer.StartSpan(ctx, "Authentication/isAuthenticationNeeded")
 	defer span.End()
 
-	if route := mux.CurrentRoute(r); route != nil {
-		for _, unauthenticatedRoute := range m.unauthenticatedRoutes {
-			if route == unauthenticatedRoute {
-				return false
-			}
-		}
-	}
-
-	return true
+	return !m.unauthenticatedRoutes.Match(r)
 }
 
 func (m *Middleware) parseFromRequest(ctx context.Context, r *http.Request) (*jwt.Token, error) {
@@ -119,15 +163,34 @@ func (m *Middleware) parseFromRequest(ctx context.Context, r *http.Request) (*jw
 		return nil, err
 	}
 
-	token, err := jwt.Parse(rawToken)
+	start := time.Now()
+	token, err := m.verify(ctx, rawToken)
+	metrics.ObserveSince(m.Metrics, metricsName, middleware.OperationVerification, r, start)
+
 	if err != nil {
 		return nil, jwtErrorToProblem(err)
 	}
 
-	return &token, nil
+	token.Raw = rawToken
+
+	if err := m.claimsValidation.validate(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
 }
 
-// decorateValidRequest attatches the Cognito and Enlight UserID onto the Request Context.
+// verify verifies the signature of the token, leaving the "exp" and "nbf"
+// claims to the claimsValidation when the verifier supports it.
+func (m *Middleware) verify(ctx context.Context, rawToken string) (*jwt.Token, error) {
+	if verifier, ok := m.TokenVerifier.(untimedVerifier); ok {
+		return verifier.verifyUntimed(ctx, rawToken)
+	}
+
+	return m.TokenVerifier.Verify(ctx, rawToken)
+}
+
+// decorateValidRequest attatches the Cognito and Enlight UserID, and the verified claims, onto the Request Context.
 func (m *Middleware) decorateValidRequest(ctx context.Context, r *http.Request, token *jwt.Token) (*http.Request, error) {
 	_, span := m.Tracer.StartSpan(ctx, "Authentication/decorateValidRequest")
 	defer span.End()
@@ -144,6 +207,9 @@ func (m *Middleware) decorateValidRequest(ctx context.Context, r *http.Request,
 	rCtx = accesstokensubcontext.NewContext(rCtx, claims.Subject)
 	rCtx = useridcontext.NewContext(rCtx, userID)
 	rCtx = impersonatercontext.NewContext(rCtx, authorID)
+	rCtx = NewClaims(claims, token.Raw).EmbedIntoContext(rCtx)
+
+	identity.FromContext(rCtx).SetUserID(userID)
 
 	return r.WithContext(rCtx), nil
 }
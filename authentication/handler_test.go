@@ -1,6 +1,7 @@
 package authentication_test
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
@@ -10,9 +11,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/SKF/go-utility/v2/jwk"
 	"github.com/SKF/go-utility/v2/jwt"
 	"github.com/SKF/go-utility/v2/useridcontext"
+	jwt_go "github.com/golang-jwt/jwt/v5"
 	jwt_request "github.com/golang-jwt/jwt/v5/request"
 	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwa"
@@ -24,11 +25,11 @@ import (
 	middleware "github.com/SKF/go-enlight-middleware"
 	"github.com/SKF/go-enlight-middleware/authentication"
 	problems "github.com/SKF/go-enlight-middleware/authentication/problems"
+	"github.com/SKF/go-enlight-middleware/internal/identity"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
-// These tests relies on global variables in packages and can't be run in
-// parallel.
-
 func createKey(t *testing.T) (ljwk.Key, ljwk.Set) {
 	// Create an RSA keypair
 	valid, err := rsa.GenerateKey(rand.Reader, 2048)
@@ -39,8 +40,6 @@ func createKey(t *testing.T) (ljwk.Key, ljwk.Set) {
 	require.NoError(t, err)
 
 	// Adds fields expected by our packages
-	// The "kid" needs to be different in each test to ensure
-	// the library refetches the keyset.
 	validKey.Set(ljwk.KeyIDKey, t.Name())      //nolint:errcheck
 	validKey.Set(ljwk.AlgorithmKey, jwa.RS256) //nolint:errcheck
 	validKey.Set(ljwk.KeyUsageKey, "sig")      //nolint:errcheck
@@ -66,8 +65,6 @@ func createJWKSServer(t *testing.T, set ljwk.Set) *httptest.Server {
 		require.NoError(t, json.NewEncoder(w).Encode(public))
 	}))
 
-	jwk.KeySetURL = s.URL
-
 	return s
 }
 
@@ -88,6 +85,8 @@ func createSignedToken(t *testing.T, key ljwk.Key, values map[string]any) []byte
 
 //nolint:bodyclose
 func Test_Middleware_AccesToken(t *testing.T) {
+	t.Parallel()
+
 	userID := uuid.New().String()
 
 	validKey, validSet := createKey(t)
@@ -97,6 +96,7 @@ func Test_Middleware_AccesToken(t *testing.T) {
 
 	h := (&authentication.Middleware{
 		TokenExtractor: jwt_request.AuthorizationHeaderExtractor,
+		TokenVerifier:  authentication.NewJWKSVerifier(s.URL),
 		Tracer:         middleware.DefaultTracer,
 	}).Middleware()
 
@@ -323,3 +323,385 @@ func Test_Middleware_AccesToken(t *testing.T) {
 		assert.Equal(t, problems.MalformedToken().Type, problem.Type)
 	})
 }
+
+//nolint:bodyclose
+func Test_Middleware_SeparateKeySets(t *testing.T) {
+	t.Parallel()
+
+	keyA, setA := createKey(t)
+	keyB, setB := createKey(t)
+
+	serverA := createJWKSServer(t, setA)
+	defer serverA.Close()
+
+	serverB := createJWKSServer(t, setB)
+	defer serverB.Close()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	middlewareA := authentication.New(authentication.WithKeySetURL(serverA.URL)).Middleware()(endpoint)
+	middlewareB := authentication.New(authentication.WithKeySetURL(serverB.URL)).Middleware()(endpoint)
+
+	claims := map[string]any{
+		"token_use": jwt.TokenUseAccess,
+		"username":  "a.b@example.com",
+	}
+
+	testCases := []struct {
+		desc     string
+		key      ljwk.Key
+		handler  http.Handler
+		expected int
+	}{
+		{desc: "Key A on middleware A", key: keyA, handler: middlewareA, expected: http.StatusOK},
+		{desc: "Key B on middleware B", key: keyB, handler: middlewareB, expected: http.StatusOK},
+		{desc: "Key A on middleware B", key: keyA, handler: middlewareB, expected: http.StatusBadRequest},
+		{desc: "Key B on middleware A", key: keyB, handler: middlewareA, expected: http.StatusBadRequest},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Add("Authorization", string(createSignedToken(t, tC.key, claims)))
+
+			w := httptest.NewRecorder()
+
+			tC.handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tC.expected, w.Result().StatusCode)
+		})
+	}
+}
+
+//nolint:bodyclose
+func Test_Middleware_ClaimValidation(t *testing.T) {
+	t.Parallel()
+
+	key, set := createKey(t)
+
+	s := createJWKSServer(t, set)
+	defer s.Close()
+
+	const (
+		issuer    = "https://cognito-idp.eu-west-1.amazonaws.com/eu-west-1_pool"
+		appClient = "app-client"
+	)
+
+	accessToken := func(overrides map[string]any) map[string]any {
+		claims := map[string]any{
+			"token_use": jwt.TokenUseAccess,
+			"username":  "a.b@example.com",
+			"iss":       issuer,
+			"client_id": appClient,
+		}
+
+		for k, v := range overrides {
+			claims[k] = v
+		}
+
+		return claims
+	}
+
+	idToken := map[string]any{
+		"token_use":     jwt.TokenUseID,
+		"enlightUserId": uuid.New().String(),
+		"iss":           issuer,
+		"aud":           "other-app-client",
+	}
+
+	testCases := []struct {
+		desc          string
+		options       []authentication.Option
+		claims        map[string]any
+		expectedClaim string
+	}{
+		{
+			desc:    "Accepted issuer",
+			options: []authentication.Option{authentication.WithIssuer(issuer)},
+			claims:  accessToken(nil),
+		},
+		{
+			desc:          "Unknown issuer",
+			options:       []authentication.Option{authentication.WithIssuer(issuer)},
+			claims:        accessToken(map[string]any{"iss": "https://example.com"}),
+			expectedClaim: `"iss"`,
+		},
+		{
+			desc:          "Missing audience",
+			options:       []authentication.Option{authentication.WithAudience("api")},
+			claims:        accessToken(nil),
+			expectedClaim: `"aud"`,
+		},
+		{
+			desc:    "Accepted access token app client",
+			options: []authentication.Option{authentication.WithAllowedAppClientIDs(appClient)},
+			claims:  accessToken(nil),
+		},
+		{
+			desc:          "Unknown access token app client",
+			options:       []authentication.Option{authentication.WithAllowedAppClientIDs(appClient)},
+			claims:        accessToken(map[string]any{"client_id": "other-app-client"}),
+			expectedClaim: `"client_id"`,
+		},
+		{
+			desc:          "Unknown identity token app client",
+			options:       []authentication.Option{authentication.WithAllowedAppClientIDs(appClient)},
+			claims:        idToken,
+			expectedClaim: `"aud"`,
+		},
+		{
+			desc:    "Expired within leeway",
+			options: []authentication.Option{authentication.WithLeeway(time.Minute)},
+			claims:  accessToken(map[string]any{ljwt.ExpirationKey: time.Now().Add(-30 * time.Second)}),
+		},
+		{
+			desc:    "Expired within leeway configured before the key set",
+			options: []authentication.Option{authentication.WithLeeway(time.Minute), authentication.WithKeySetURL(s.URL)},
+			claims:  accessToken(map[string]any{ljwt.ExpirationKey: time.Now().Add(-30 * time.Second)}),
+		},
+		{
+			desc:    "Expired within leeway configured before the token verifier",
+			options: []authentication.Option{authentication.WithLeeway(time.Minute), authentication.WithTokenVerifier(authentication.NewJWKSVerifier(s.URL))},
+			claims:  accessToken(map[string]any{ljwt.ExpirationKey: time.Now().Add(-30 * time.Second)}),
+		},
+		{
+			desc:    "Expired within leeway configured after the token verifier",
+			options: []authentication.Option{authentication.WithTokenVerifier(authentication.NewJWKSVerifier(s.URL)), authentication.WithLeeway(time.Minute)},
+			claims:  accessToken(map[string]any{ljwt.ExpirationKey: time.Now().Add(-30 * time.Second)}),
+		},
+		{
+			desc:          "Expired outside of leeway",
+			options:       []authentication.Option{authentication.WithLeeway(time.Minute)},
+			claims:        accessToken(map[string]any{ljwt.ExpirationKey: time.Now().Add(-2 * time.Minute)}),
+			expectedClaim: "expired",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			options := append([]authentication.Option{authentication.WithKeySetURL(s.URL)}, tC.options...)
+
+			h := authentication.New(options...).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Add("Authorization", string(createSignedToken(t, key, tC.claims)))
+
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+
+			if tC.expectedClaim == "" {
+				assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+				return
+			}
+
+			assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+
+			var problem problems.InvalidTokenProblem
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&problem))
+
+			assert.Equal(t, problems.InvalidToken("").Type, problem.Type)
+			assert.Contains(t, problem.Detail, tC.expectedClaim)
+		})
+	}
+}
+
+func Test_Middleware_LeewayLeavesTokenVerifierUnchanged(t *testing.T) {
+	t.Parallel()
+
+	verifier := authentication.NewJWKSVerifier("https://example.com/jwks")
+
+	authentication.New(authentication.WithTokenVerifier(verifier), authentication.WithLeeway(time.Minute))
+
+	assert.Zero(t, verifier.Leeway)
+}
+
+//nolint:bodyclose
+func Test_Middleware_ClaimsFromContext(t *testing.T) {
+	t.Parallel()
+
+	key, set := createKey(t)
+
+	s := createJWKSServer(t, set)
+	defer s.Close()
+
+	var (
+		userID    = uuid.New().String()
+		companyID = uuid.New().String()
+	)
+
+	signed := createSignedToken(t, key, map[string]any{
+		"sub":           "cognito-subject",
+		"token_use":     jwt.TokenUseID,
+		"enlightUserId": userID,
+		"cognito:groups": []string{
+			fmt.Sprintf("enlightUserId:%s", userID),
+		},
+		"enlightCompanyId": companyID,
+		"enlightRoles":     "hierarchy_admin, user_admin",
+		"enlightEmail":     "a.b@example.com",
+		"email":            "a.b@example.com",
+	})
+
+	var claims authentication.Claims
+
+	h := authentication.New(authentication.WithKeySetURL(s.URL)).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ok bool
+
+		claims, ok = authentication.ClaimsFromContext(r.Context())
+		require.True(t, ok)
+	}))
+
+	ctx, holder := identity.NewContext(context.Background())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header.Add("Authorization", "Bearer "+string(signed))
+
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, userID, holder.UserID(), "the identity is shared with wrapping middlewares")
+
+	assert.Equal(t, string(signed), claims.RawToken())
+	assert.Equal(t, authentication.TokenUseID, claims.TokenUse())
+	assert.Equal(t, "cognito-subject", claims.Subject())
+	assert.Equal(t, userID, claims.UserID())
+	assert.Equal(t, companyID, claims.EnlightCompanyID())
+	assert.Equal(t, []string{"hierarchy_admin", "user_admin"}, claims.EnlightRoles())
+	assert.Equal(t, "a.b@example.com", claims.EnlightEmail())
+
+	email, found := claims.Claim("email")
+	assert.True(t, found, "claims unknown by jwt.Claims are available")
+	assert.Equal(t, "a.b@example.com", email)
+
+	groups := claims.CognitoGroups()
+	groups[0] = "modified"
+
+	assert.Equal(t, fmt.Sprintf("enlightUserId:%s", userID), claims.CognitoGroups()[0])
+}
+
+func Test_ClaimsFromContext_Missing(t *testing.T) {
+	t.Parallel()
+
+	_, ok := authentication.ClaimsFromContext(context.Background())
+	require.False(t, ok)
+}
+
+func Test_Middleware_IgnoreMatching(t *testing.T) {
+	t.Parallel()
+
+	h := authentication.New(authentication.WithKeySetURL("")).
+		IgnoreMatching(route.All{route.PathPrefix("/health"), route.Methods(http.MethodGet)}).
+		Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testCases := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{method: http.MethodGet, path: "/health/ready", expected: http.StatusOK},
+		{method: http.MethodPost, path: "/health/ready", expected: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/nodes", expected: http.StatusUnauthorized},
+	}
+
+	for _, tC := range testCases {
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, httptest.NewRequest(tC.method, tC.path, nil))
+
+		assert.Equal(t, tC.expected, w.Code, tC.method+" "+tC.path)
+	}
+}
+
+func Test_Middleware_IgnoreMatchingOnServeMux(t *testing.T) {
+	t.Parallel()
+
+	mw := authentication.New(authentication.WithKeySetURL("")).
+		IgnoreMatching(route.Pattern("GET /health"))
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serveMux.Handle("GET /nodes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h := route.Middleware(route.ServeMux(serveMux))(mw.Middleware()(serveMux))
+
+	for path, expected := range map[string]int{
+		"/health": http.StatusOK,
+		"/nodes":  http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, expected, w.Code, path)
+	}
+}
+
+type failingVerifier struct{}
+
+func (failingVerifier) Verify(context.Context, string) (*jwt.Token, error) {
+	return nil, jwt_go.ErrTokenExpired
+}
+
+func Test_Middleware_Metrics(t *testing.T) {
+	t.Parallel()
+
+	recorder := new(metrics.Recorder)
+
+	mw := authentication.New(authentication.WithTokenVerifier(failingVerifier{})).
+		IgnoreMatching(route.Pattern("GET /health"))
+	mw.Metrics = recorder
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serveMux.Handle("GET /nodes/{nodeId}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h := route.Middleware(route.ServeMux(serveMux))(mw.Middleware()(serveMux))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/1", nil))
+
+	expired := httptest.NewRequest(http.MethodGet, "/nodes/1", nil)
+	expired.Header.Set("Authorization", "Bearer token")
+	h.ServeHTTP(httptest.NewRecorder(), expired)
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "authentication", Route: "GET /health", Outcome: middleware.OutcomeSkipped},
+		{Middleware: "authentication", Route: "GET /nodes/{nodeId}", Outcome: middleware.OutcomeRejected, ProblemType: "/problems/missing-authentication-token"},
+		{Middleware: "authentication", Route: "GET /nodes/{nodeId}", Outcome: middleware.OutcomeRejected, ProblemType: "/problems/expired-authentication-token"},
+	}, recorder.Requests())
+
+	require.Equal(t, []metrics.Duration{
+		{Middleware: "authentication", Operation: middleware.OperationVerification, Route: "GET /nodes/{nodeId}"},
+	}, recorder.Durations())
+}
+
+func Test_Middleware_Stop(t *testing.T) {
+	t.Parallel()
+
+	_, set := createKey(t)
+
+	s := newJWKSServer(t, set)
+	defer s.Close()
+
+	verifier := authentication.NewJWKSVerifier(s.URL)
+	verifier.KeySet.RefreshInterval = time.Millisecond
+
+	mw := authentication.New(authentication.WithTokenVerifier(verifier))
+	mw.Middleware()
+
+	require.Eventually(t, func() bool {
+		return s.fetches.Load() >= 2
+	}, time.Second, time.Millisecond)
+
+	mw.Stop()
+
+	// A refresh might have been in flight while stopping.
+	time.Sleep(10 * time.Millisecond)
+	fetched := s.fetches.Load()
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, fetched, s.fetches.Load())
+}
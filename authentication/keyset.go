@@ -0,0 +1,284 @@
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwk"
+	"github.com/SKF/go-utility/v2/log"
+)
+
+const (
+	DefaultKeySetRefreshInterval    = 1 * time.Hour
+	DefaultKeySetMinRefreshInterval = 1 * time.Minute
+	DefaultKeySetInitialBackoff     = 1 * time.Second
+	DefaultKeySetMaxBackoff         = 1 * time.Minute
+
+	defaultKeySetFetchTimeout = 10 * time.Second
+)
+
+var (
+	ErrKeySetNotLoaded = errors.New("no JWKS has been loaded yet")
+	ErrUnknownKeyID    = errors.New("unable to find public key")
+)
+
+// KeySetManager keeps the JWKS published at URL up to date.
+//
+// Once started the key set is refreshed every RefreshInterval, failed fetches are
+// retried with an exponential backoff between InitialBackoff and MaxBackoff. An
+// unknown key ID triggers a refetch, but at most once every MinRefreshInterval.
+// When a fetch fails the previously fetched key set keeps being used.
+type KeySetManager struct {
+	URL                string
+	HTTPClient         *http.Client
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+
+	refreshLock     sync.Mutex
+	lastMissRefresh time.Time
+
+	keySetsLock sync.RWMutex
+	keySets     jwk.JWKeySets
+	lastRefresh time.Time
+	lastErr     error
+
+	startOnce sync.Once
+	stop      context.CancelFunc
+}
+
+func NewKeySetManager(url string) *KeySetManager {
+	return &KeySetManager{
+		URL:                url,
+		HTTPClient:         &http.Client{Timeout: defaultKeySetFetchTimeout},
+		RefreshInterval:    DefaultKeySetRefreshInterval,
+		MinRefreshInterval: DefaultKeySetMinRefreshInterval,
+		InitialBackoff:     DefaultKeySetInitialBackoff,
+		MaxBackoff:         DefaultKeySetMaxBackoff,
+	}
+}
+
+// Start fetches the key set and keeps refreshing it in the background until
+// ctx is cancelled or Stop is called. Calling Start more than once has no effect.
+func (m *KeySetManager) Start(ctx context.Context) {
+	m.startOnce.Do(func() {
+		ctx, cancel := context.WithCancel(ctx)
+
+		m.keySetsLock.Lock()
+		m.stop = cancel
+		m.keySetsLock.Unlock()
+
+		go m.run(ctx)
+	})
+}
+
+// Stop ends the background refresh started by Start.
+func (m *KeySetManager) Stop() {
+	m.keySetsLock.RLock()
+	stop := m.stop
+	m.keySetsLock.RUnlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// Ready returns nil once a key set has been loaded, until then the error
+// of the latest fetch attempt is returned.
+func (m *KeySetManager) Ready() error {
+	m.keySetsLock.RLock()
+	defer m.keySetsLock.RUnlock()
+
+	if m.lastRefresh.IsZero() {
+		if m.lastErr != nil {
+			return fmt.Errorf("%w: %w", ErrKeySetNotLoaded, m.lastErr)
+		}
+
+		return ErrKeySetNotLoaded
+	}
+
+	return nil
+}
+
+// Refresh fetches the key set, on failure the current key set is kept.
+func (m *KeySetManager) Refresh(ctx context.Context) error {
+	m.refreshLock.Lock()
+	defer m.refreshLock.Unlock()
+
+	return m.refresh(ctx)
+}
+
+// LookupKeyID returns the signing key with the given key ID. If the key is not
+// part of the current key set it is refetched, unless that was done within
+// MinRefreshInterval. The refetch is not canceled together with ctx, as it
+// would still use up the MinRefreshInterval.
+func (m *KeySetManager) LookupKeyID(ctx context.Context, keyID string) (jwk.JWKeySet, error) {
+	if key, found := m.findKeyID(keyID); found {
+		return key, nil
+	}
+
+	m.refreshLock.Lock()
+	defer m.refreshLock.Unlock()
+
+	// A concurrent lookup might already have fetched the key.
+	if key, found := m.findKeyID(keyID); found {
+		return key, nil
+	}
+
+	loaded := m.Ready() == nil
+
+	if !m.lastMissRefresh.IsZero() && time.Since(m.lastMissRefresh) < m.MinRefreshInterval {
+		if !loaded {
+			return jwk.JWKeySet{}, ErrKeySetNotLoaded
+		}
+
+		return jwk.JWKeySet{}, ErrUnknownKeyID
+	}
+
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultKeySetFetchTimeout)
+	defer cancel()
+
+	err := m.refresh(refreshCtx)
+
+	// Lazily loading the first key set is not a miss, but failing to do so is.
+	if loaded || err != nil {
+		m.lastMissRefresh = time.Now()
+	}
+
+	if err != nil {
+		return jwk.JWKeySet{}, err
+	}
+
+	if key, found := m.findKeyID(keyID); found {
+		return key, nil
+	}
+
+	return jwk.JWKeySet{}, ErrUnknownKeyID
+}
+
+func (m *KeySetManager) run(ctx context.Context) {
+	interval := orDefault(m.RefreshInterval, DefaultKeySetRefreshInterval)
+	initialBackoff := orDefault(m.InitialBackoff, DefaultKeySetInitialBackoff)
+	maxBackoff := orDefault(m.MaxBackoff, DefaultKeySetMaxBackoff)
+
+	backoff := initialBackoff
+
+	for {
+		wait := interval
+
+		if err := m.Refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.WithError(err).
+				WithField("url", m.URL).
+				WithField("retryIn", backoff.String()).
+				Error("Unable to refresh JWKS in AuthenticationMiddleware")
+
+			wait = min(backoff, interval)
+			backoff = min(2*backoff, maxBackoff) //nolint:mnd
+		} else {
+			backoff = initialBackoff
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *KeySetManager) findKeyID(keyID string) (jwk.JWKeySet, bool) {
+	m.keySetsLock.RLock()
+	defer m.keySetsLock.RUnlock()
+
+	for _, ks := range m.keySets {
+		if ks.KeyID == keyID && ks.Use == "sig" {
+			return ks, true
+		}
+	}
+
+	return jwk.JWKeySet{}, false
+}
+
+func (m *KeySetManager) refresh(ctx context.Context) error {
+	keySets, err := fetchKeySets(ctx, m.httpClient(), m.URL)
+
+	m.keySetsLock.Lock()
+	defer m.keySetsLock.Unlock()
+
+	m.lastErr = err
+
+	if err != nil {
+		return err
+	}
+
+	m.keySets = keySets
+	m.lastRefresh = time.Now()
+
+	return nil
+}
+
+func (m *KeySetManager) httpClient() *http.Client {
+	if m.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return m.HTTPClient
+}
+
+func fetchKeySets(ctx context.Context, client *http.Client, url string) (jwk.JWKeySets, error) {
+	if url == "" {
+		return nil, errors.New("no key set URL configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key sets request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key sets: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non 200 status code when fetching key sets, %d", resp.StatusCode)
+	}
+
+	var data map[string]jwk.JWKeySets
+
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key sets: %w", err)
+	}
+
+	// Cognito uses "Keys", RFC 7517 "keys" and the SSO-API "data".
+	for _, field := range []string{"Keys", "keys", "data"} {
+		if keys, present := data[field]; present {
+			return keys, nil
+		}
+	}
+
+	return nil, errors.New("failed to find key sets in response")
+}
+
+func orDefault(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+
+	return d
+}
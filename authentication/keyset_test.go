@@ -0,0 +1,174 @@
+package authentication_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ljwk "github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/authentication"
+)
+
+type jwksServer struct {
+	*httptest.Server
+
+	fetches atomic.Int32
+	failing atomic.Bool
+	set     atomic.Value
+}
+
+func newJWKSServer(t *testing.T, set ljwk.Set) *jwksServer {
+	s := new(jwksServer)
+	s.set.Store(set)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.fetches.Add(1)
+
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		public, err := ljwk.PublicSetOf(s.set.Load().(ljwk.Set))
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(public))
+	}))
+
+	return s
+}
+
+func TestKeySetManager_UnknownKeyIDIsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	_, set := createKey(t)
+
+	s := newJWKSServer(t, set)
+	defer s.Close()
+
+	ctx := context.Background()
+	manager := authentication.NewKeySetManager(s.URL)
+	manager.MinRefreshInterval = time.Hour
+
+	_, err := manager.LookupKeyID(ctx, t.Name())
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = manager.LookupKeyID(ctx, "unknown")
+		require.ErrorIs(t, err, authentication.ErrUnknownKeyID)
+	}
+
+	require.Equal(t, int32(2), s.fetches.Load())
+}
+
+func TestKeySetManager_LookupIsNotCanceledWithCaller(t *testing.T) {
+	t.Parallel()
+
+	_, set := createKey(t)
+
+	s := newJWKSServer(t, set)
+	defer s.Close()
+
+	manager := authentication.NewKeySetManager(s.URL)
+	manager.MinRefreshInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := manager.LookupKeyID(ctx, t.Name())
+	require.NoError(t, err)
+	require.NoError(t, manager.Ready())
+}
+
+func TestKeySetManager_RetriesStartupFailure(t *testing.T) {
+	t.Parallel()
+
+	_, set := createKey(t)
+
+	s := newJWKSServer(t, set)
+	defer s.Close()
+
+	s.failing.Store(true)
+
+	manager := authentication.NewKeySetManager(s.URL)
+	manager.InitialBackoff = time.Millisecond
+	manager.MaxBackoff = 5 * time.Millisecond
+
+	manager.Start(context.Background())
+	defer manager.Stop()
+
+	require.Eventually(t, func() bool {
+		return s.fetches.Load() >= 3
+	}, time.Second, time.Millisecond)
+
+	require.ErrorIs(t, manager.Ready(), authentication.ErrKeySetNotLoaded)
+
+	s.failing.Store(false)
+
+	require.Eventually(t, func() bool {
+		return manager.Ready() == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestKeySetManager_ServesStaleKeySet(t *testing.T) {
+	t.Parallel()
+
+	_, set := createKey(t)
+
+	s := newJWKSServer(t, set)
+	defer s.Close()
+
+	ctx := context.Background()
+	manager := authentication.NewKeySetManager(s.URL)
+
+	require.NoError(t, manager.Refresh(ctx))
+
+	s.failing.Store(true)
+
+	require.Error(t, manager.Refresh(ctx))
+	require.NoError(t, manager.Ready())
+
+	_, err := manager.LookupKeyID(ctx, t.Name())
+	require.NoError(t, err)
+}
+
+func TestKeySetManager_PeriodicRefresh(t *testing.T) {
+	t.Parallel()
+
+	_, oldSet := createKey(t)
+
+	newKey, newSet := createKey(t)
+	require.NoError(t, newKey.Set(ljwk.KeyIDKey, "rotated"))
+
+	s := newJWKSServer(t, oldSet)
+	defer s.Close()
+
+	manager := authentication.NewKeySetManager(s.URL)
+	manager.RefreshInterval = 5 * time.Millisecond
+	manager.MinRefreshInterval = time.Hour
+
+	manager.Start(context.Background())
+	defer manager.Stop()
+
+	require.Eventually(t, func() bool {
+		return manager.Ready() == nil
+	}, time.Second, time.Millisecond)
+
+	s.set.Store(newSet)
+	fetched := s.fetches.Load()
+
+	require.Eventually(t, func() bool {
+		return s.fetches.Load() >= fetched+2
+	}, time.Second, time.Millisecond)
+
+	// The key must already be known, a refetch on the unknown key ID would fail.
+	s.failing.Store(true)
+
+	_, err := manager.LookupKeyID(context.Background(), "rotated")
+	require.NoError(t, err)
+}
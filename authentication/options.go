@@ -1,11 +1,53 @@
 package authentication
 
-import "github.com/SKF/go-utility/v2/jwk"
+import "time"
 
 type Option func(*Middleware)
 
+// WithStage verifies tokens against the JWKS of the Enlight SSO in the given stage.
 func WithStage(stage string) Option {
+	return WithKeySetURL(KeySetURL(stage))
+}
+
+// WithKeySetURL verifies tokens against the JWKS published at url.
+func WithKeySetURL(url string) Option {
+	return WithTokenVerifier(NewJWKSVerifier(url))
+}
+
+func WithTokenVerifier(verifier TokenVerifier) Option {
+	return func(m *Middleware) {
+		m.TokenVerifier = verifier
+	}
+}
+
+// WithIssuer only accepts tokens with one of the given "iss" claims, e.g.
+// "https://cognito-idp.eu-west-1.amazonaws.com/<user pool id>".
+func WithIssuer(issuers ...string) Option {
+	return func(m *Middleware) {
+		m.claimsValidation.issuers = append(m.claimsValidation.issuers, issuers...)
+	}
+}
+
+// WithAudience only accepts tokens with at least one of the given audiences in
+// the "aud" claim. Note that Cognito access tokens have no "aud" claim.
+func WithAudience(audiences ...string) Option {
+	return func(m *Middleware) {
+		m.claimsValidation.audiences = append(m.claimsValidation.audiences, audiences...)
+	}
+}
+
+// WithAllowedAppClientIDs only accepts tokens issued to one of the given Cognito
+// app clients, read from "aud" of ID tokens and "client_id" of access tokens.
+func WithAllowedAppClientIDs(clientIDs ...string) Option {
+	return func(m *Middleware) {
+		m.claimsValidation.appClientIDs = append(m.claimsValidation.appClientIDs, clientIDs...)
+	}
+}
+
+// WithLeeway allows for clock skew when validating the "exp" and "nbf" claims,
+// regardless of the Leeway of a JWKSVerifier given to WithTokenVerifier.
+func WithLeeway(leeway time.Duration) Option {
 	return func(m *Middleware) {
-		jwk.Configure(jwk.Config{Stage: stage})
+		m.claimsValidation.leeway = leeway
 	}
 }
@@ -0,0 +1,89 @@
+package authentication
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwt"
+	jwt_go "github.com/golang-jwt/jwt/v5"
+
+	custom_problems "github.com/SKF/go-enlight-middleware/authentication/problems"
+)
+
+// claimsValidation holds the claim requirements configured through options,
+// empty requirements are not enforced.
+type claimsValidation struct {
+	issuers      []string
+	audiences    []string
+	appClientIDs []string
+	leeway       time.Duration
+}
+
+// appClientClaims contains the app client of Cognito access tokens, which is
+// not part of jwt.Claims.
+type appClientClaims struct {
+	jwt_go.RegisteredClaims
+	ClientID string `json:"client_id"`
+}
+
+func (v claimsValidation) validate(token *jwt.Token) error {
+	claims := token.GetClaims()
+
+	if err := jwt_go.NewValidator(jwt_go.WithLeeway(v.leeway)).Validate(claims); err != nil {
+		return jwtErrorToProblem(invalidClaims(err))
+	}
+
+	if len(v.issuers) > 0 && !slices.Contains(v.issuers, claims.Issuer) {
+		return custom_problems.InvalidToken(fmt.Sprintf(`The "iss" claim %q is not an accepted issuer.`, claims.Issuer))
+	}
+
+	if len(v.audiences) > 0 && !containsAny(v.audiences, claims.Audience) {
+		return custom_problems.InvalidToken(fmt.Sprintf(`The "aud" claim %q does not contain an accepted audience.`, claims.Audience))
+	}
+
+	if len(v.appClientIDs) > 0 {
+		return v.validateAppClient(token, claims)
+	}
+
+	return nil
+}
+
+// validateAppClient checks the app client the token was issued to. Cognito
+// puts it in "aud" of ID tokens and in "client_id" of access tokens.
+func (v claimsValidation) validateAppClient(token *jwt.Token, claims jwt.Claims) error {
+	if claims.TokenUse == jwt.TokenUseID {
+		if !containsAny(v.appClientIDs, claims.Audience) {
+			return custom_problems.InvalidToken(fmt.Sprintf(`The "aud" claim %q is not an accepted app client.`, claims.Audience))
+		}
+
+		return nil
+	}
+
+	var appClient appClientClaims
+
+	if _, _, err := jwt_go.NewParser().ParseUnverified(token.Raw, &appClient); err != nil {
+		return fmt.Errorf("unable to decode already verified token: %w", err)
+	}
+
+	if !slices.Contains(v.appClientIDs, appClient.ClientID) {
+		return custom_problems.InvalidToken(fmt.Sprintf(`The "client_id" claim %q is not an accepted app client.`, appClient.ClientID))
+	}
+
+	return nil
+}
+
+// invalidClaims wraps a validation error the same way as jwt_go.Parser does.
+func invalidClaims(err error) error {
+	return fmt.Errorf("claims validation failed: %w", fmt.Errorf("%w: %w", jwt_go.ErrTokenInvalidClaims, err))
+}
+
+func containsAny(accepted, values []string) bool {
+	for _, value := range values {
+		if slices.Contains(accepted, value) {
+			return true
+		}
+	}
+
+	return false
+}
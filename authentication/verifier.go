@@ -0,0 +1,132 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwt"
+	"github.com/SKF/go-utility/v2/stages"
+	jwt_go "github.com/golang-jwt/jwt/v5"
+)
+
+var ErrMissingKeyID = errors.New("expecting JWT header to have string `kid`")
+
+// TokenVerifier verifies the signature of a raw JWT token. The claims of the
+// returned token are additionally validated by the middleware.
+type TokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*jwt.Token, error)
+}
+
+// backgroundVerifier is implemented by verifiers which keep their keys up to
+// date in the background once the middleware is created.
+type backgroundVerifier interface {
+	Start(ctx context.Context)
+	Stop()
+	Ready() error
+}
+
+// untimedVerifier is implemented by verifiers able to leave the validation of
+// the "exp" and "nbf" claims to the middleware, which applies its own leeway.
+type untimedVerifier interface {
+	verifyUntimed(ctx context.Context, rawToken string) (*jwt.Token, error)
+}
+
+// JWKSVerifier verifies Cognito and SSO tokens using the public keys of its own
+// KeySetManager. The "exp" and "nbf" claims are validated allowing for Leeway,
+// except when used by the middleware which validates them using WithLeeway.
+type JWKSVerifier struct {
+	KeySet *KeySetManager
+	Leeway time.Duration
+}
+
+type VerifierOption func(*JWKSVerifier)
+
+// WithVerifierLeeway allows for clock skew when validating the "exp" and "nbf" claims.
+func WithVerifierLeeway(leeway time.Duration) VerifierOption {
+	return func(v *JWKSVerifier) {
+		v.Leeway = leeway
+	}
+}
+
+func NewJWKSVerifier(keySetURL string, opts ...VerifierOption) *JWKSVerifier {
+	v := &JWKSVerifier{
+		KeySet: NewKeySetManager(keySetURL),
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// KeySetURL returns the URL of the JWKS published by the Enlight SSO in the given stage.
+func KeySetURL(stage string) string {
+	if stage == stages.StageProd {
+		return "https://sso-api.users.enlight.skf.com/jwks"
+	}
+
+	return fmt.Sprintf("https://sso-api.%s.users.enlight.skf.com/jwks", stage)
+}
+
+func (v *JWKSVerifier) Verify(ctx context.Context, rawToken string) (*jwt.Token, error) {
+	return v.verify(ctx, rawToken, jwt_go.WithLeeway(v.Leeway))
+}
+
+func (v *JWKSVerifier) verifyUntimed(ctx context.Context, rawToken string) (*jwt.Token, error) {
+	return v.verify(ctx, rawToken, jwt_go.WithoutClaimsValidation())
+}
+
+func (v *JWKSVerifier) verify(ctx context.Context, rawToken string, opts ...jwt_go.ParserOption) (*jwt.Token, error) {
+	keyFunc := func(token *jwt_go.Token) (any, error) {
+		return v.keyFunc(ctx, token)
+	}
+
+	token, err := jwt_go.ParseWithClaims(rawToken, &jwt.Claims{}, keyFunc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("parse with claims failed: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	verified := jwt.Token(*token)
+
+	return &verified, nil
+}
+
+// Start keeps the key set refreshed in the background until ctx is cancelled.
+func (v *JWKSVerifier) Start(ctx context.Context) {
+	v.KeySet.Start(ctx)
+}
+
+// Stop ends the background refresh of the key set.
+func (v *JWKSVerifier) Stop() {
+	v.KeySet.Stop()
+}
+
+// Ready returns nil once the key set has been loaded.
+func (v *JWKSVerifier) Ready() error {
+	return v.KeySet.Ready()
+}
+
+func (v *JWKSVerifier) keyFunc(ctx context.Context, token *jwt_go.Token) (any, error) {
+	keyID, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrMissingKeyID
+	}
+
+	key, err := v.KeySet.LookupKeyID(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup key id: %w", err)
+	}
+
+	if key.Algorithm != "" && key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("token algorithm %s does not match key algorithm %s", token.Method.Alg(), key.Algorithm)
+	}
+
+	return key.GetPublicKey()
+}
@@ -0,0 +1,85 @@
+package authentication_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SKF/go-utility/v2/jwt"
+	jwt_go "github.com/golang-jwt/jwt/v5"
+	ljwk "github.com/lestrrat-go/jwx/v2/jwk"
+	ljwt "github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/authentication"
+)
+
+func TestJWKSVerifier_RefetchOnUnknownKeyID(t *testing.T) {
+	t.Parallel()
+
+	oldKey, oldSet := createKey(t)
+	newKey, newSet := createKey(t)
+
+	require.NoError(t, newKey.Set(ljwk.KeyIDKey, "rotated"))
+
+	var (
+		fetches atomic.Int32
+		current atomic.Value
+	)
+
+	current.Store(oldSet)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+
+		public, err := ljwk.PublicSetOf(current.Load().(ljwk.Set))
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(public))
+	}))
+	defer s.Close()
+
+	verifier := authentication.NewJWKSVerifier(s.URL)
+	ctx := context.Background()
+	claims := map[string]any{
+		"token_use": jwt.TokenUseAccess,
+		"username":  "a.b@example.com",
+	}
+
+	_, err := verifier.Verify(ctx, string(createSignedToken(t, oldKey, claims)))
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(ctx, string(createSignedToken(t, oldKey, claims)))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), fetches.Load())
+
+	current.Store(newSet)
+
+	_, err = verifier.Verify(ctx, string(createSignedToken(t, newKey, claims)))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKSVerifier_ValidatesExpiration(t *testing.T) {
+	t.Parallel()
+
+	key, set := createKey(t)
+
+	s := createJWKSServer(t, set)
+	defer s.Close()
+
+	expired := string(createSignedToken(t, key, map[string]any{
+		"token_use":        jwt.TokenUseAccess,
+		"username":         "a.b@example.com",
+		ljwt.ExpirationKey: time.Now().Add(-30 * time.Second),
+	}))
+
+	_, err := authentication.NewJWKSVerifier(s.URL).Verify(context.Background(), expired)
+	require.ErrorIs(t, err, jwt_go.ErrTokenExpired)
+
+	_, err = authentication.NewJWKSVerifier(s.URL, authentication.WithVerifierLeeway(time.Minute)).Verify(context.Background(), expired)
+	require.NoError(t, err)
+}
@@ -0,0 +1,160 @@
+package authorization
+
+import (
+	"context"
+
+	authorize "github.com/SKF/go-enlight-authorizer/client"
+	proto "github.com/SKF/proto/v2/common"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBulkConcurrency is the number of concurrent calls made to authorize
+// many resources using an AuthorizerClient without support for bulk calls.
+const DefaultBulkConcurrency = 10
+
+// BulkAuthorizerClient is implemented by AuthorizerClients able to authorize
+// many resources in a single call, e.g. the go-enlight-authorizer client.
+type BulkAuthorizerClient interface {
+	AuthorizerClient
+	IsAuthorizedBulk(ctx context.Context, userID, action string, resources []*proto.Origin) ([]*proto.Origin, []bool, error)
+}
+
+// AuthorizeBulk returns if the user is authorized to perform the action on each
+// of the resources. BulkAuthorizerClients are called in batches, other clients
+// are called once per resource with at most concurrency calls at a time.
+func AuthorizeBulk(ctx context.Context, client AuthorizerClient, userID, action string, resources []*proto.Origin, concurrency int) ([]bool, error) {
+	if bulk, ok := client.(BulkAuthorizerClient); ok {
+		// Passed on to BulkAuthorizerClients calling another client once per
+		// resource, e.g. the CachingAuthorizerClient.
+		ctx = context.WithValue(ctx, bulkConcurrencyContextKey{}, concurrency)
+
+		return authorizeInBatches(ctx, bulk, userID, action, resources)
+	}
+
+	return authorizeConcurrently(ctx, client, userID, action, resources, concurrency)
+}
+
+type bulkConcurrencyContextKey struct{}
+
+// bulkConcurrencyFromContext returns the concurrency given to AuthorizeBulk, or
+// fallback if the call was not made by AuthorizeBulk.
+func bulkConcurrencyFromContext(ctx context.Context, fallback int) int {
+	if concurrency, ok := ctx.Value(bulkConcurrencyContextKey{}).(int); ok {
+		return concurrency
+	}
+
+	return fallback
+}
+
+type originKey struct {
+	resourceType string
+	id           string
+}
+
+func authorizeInBatches(ctx context.Context, client BulkAuthorizerClient, userID, action string, resources []*proto.Origin) ([]bool, error) {
+	allowed := make([]bool, len(resources))
+
+	for start := 0; start < len(resources); start += authorize.REQUEST_LENGTH_LIMIT {
+		batch := resources[start:min(start+authorize.REQUEST_LENGTH_LIMIT, len(resources))]
+
+		returned, oks, err := client.IsAuthorizedBulk(ctx, userID, action, batch)
+		if err != nil {
+			return nil, authorizerError("IsAuthorizedBulk", err)
+		}
+
+		copy(allowed[start:], matchDecisions(batch, returned, oks))
+	}
+
+	return allowed, nil
+}
+
+// matchDecisions returns the decisions of a bulk call in the order of the
+// requested resources. The responses are matched on the resources, as older
+// authorizers only return the id of the resources in any order.
+func matchDecisions(requested, returned []*proto.Origin, oks []bool) []bool {
+	decisions := make(map[originKey]bool, len(returned))
+	for i, resource := range returned {
+		if i < len(oks) {
+			decisions[originKey{resourceType: resource.GetType(), id: resource.GetId()}] = oks[i]
+		}
+	}
+
+	allowed := make([]bool, len(requested))
+
+	for i, resource := range requested {
+		ok, found := decisions[originKey{resourceType: resource.GetType(), id: resource.GetId()}]
+		if !found {
+			ok = decisions[originKey{id: resource.GetId()}]
+		}
+
+		allowed[i] = ok
+	}
+
+	return allowed
+}
+
+func authorizeConcurrently(ctx context.Context, client AuthorizerClient, userID, action string, resources []*proto.Origin, concurrency int) ([]bool, error) {
+	allowed := make([]bool, len(resources))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(max(concurrency, 1))
+
+	for i, resource := range resources {
+		group.Go(func() error {
+			ok, _, err := client.IsAuthorizedWithReason(ctx, userID, action, resource)
+			if err != nil {
+				return authorizerError("IsAuthorizedWithReasonWithContext", err)
+			}
+
+			allowed[i] = ok
+
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return allowed, nil
+}
+
+// ResourceFilter filters resources down to the ones the user of the request is
+// authorized to access, intended to be used by list endpoints.
+type ResourceFilter struct {
+	client      AuthorizerClient
+	userID      string
+	concurrency int
+}
+
+// Filter returns the resources the user is authorized to perform the action on,
+// keeping their order.
+func (f ResourceFilter) Filter(ctx context.Context, action string, resources []*proto.Origin) ([]*proto.Origin, error) {
+	allowed, err := AuthorizeBulk(ctx, f.client, f.userID, action, resources, f.concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*proto.Origin, 0, len(resources))
+
+	for i, resource := range resources {
+		if allowed[i] {
+			filtered = append(filtered, resource)
+		}
+	}
+
+	return filtered, nil
+}
+
+type resourceFilterContextKey struct{}
+
+// FilterFromContext returns the ResourceFilter of the authenticated user, which
+// is embedded by the middleware into the context of authorized requests.
+func FilterFromContext(ctx context.Context) (ResourceFilter, bool) {
+	filter, ok := ctx.Value(resourceFilterContextKey{}).(ResourceFilter)
+	return filter, ok
+}
+
+func newFilterContext(ctx context.Context, filter ResourceFilter) context.Context {
+	return context.WithValue(ctx, resourceFilterContextKey{}, filter)
+}
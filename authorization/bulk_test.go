@@ -0,0 +1,152 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SKF/go-utility/v2/useridcontext"
+	proto "github.com/SKF/proto/v2/common"
+	"github.com/stretchr/testify/require"
+)
+
+func origins(n int) []*proto.Origin {
+	resources := make([]*proto.Origin, n)
+	for i := range resources {
+		resources[i] = &proto.Origin{Id: fmt.Sprint(i), Type: "node"}
+	}
+
+	return resources
+}
+
+// evenAuthorizer authorizes resources with an even id, tracking the maximum
+// number of concurrent calls.
+type evenAuthorizer struct {
+	mutex      sync.Mutex
+	active     int
+	maxActive  int
+	calls      atomic.Int32
+	err        error
+	resourceID string
+}
+
+func (a *evenAuthorizer) IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error) {
+	a.calls.Add(1)
+
+	a.mutex.Lock()
+	a.active++
+	a.maxActive = max(a.maxActive, a.active)
+	a.mutex.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	a.mutex.Lock()
+	a.active--
+	a.mutex.Unlock()
+
+	if a.err != nil && resource.GetId() == a.resourceID {
+		return false, "", a.err
+	}
+
+	return isEven(resource), "", nil
+}
+
+func isEven(resource *proto.Origin) bool {
+	var id int
+	fmt.Sscan(resource.GetId(), &id) //nolint:errcheck
+
+	return id%2 == 0
+}
+
+// evenBulkAuthorizer answers in reverse order without resource types, like
+// older authorizers do.
+type evenBulkAuthorizer struct {
+	evenAuthorizer
+
+	batches []int
+}
+
+func (a *evenBulkAuthorizer) IsAuthorizedBulk(ctx context.Context, userID, action string, resources []*proto.Origin) ([]*proto.Origin, []bool, error) {
+	a.batches = append(a.batches, len(resources))
+
+	returned := make([]*proto.Origin, 0, len(resources))
+	oks := make([]bool, 0, len(resources))
+
+	for _, resource := range slices.Backward(resources) {
+		returned = append(returned, &proto.Origin{Id: resource.GetId()})
+		oks = append(oks, isEven(resource))
+	}
+
+	return returned, oks, nil
+}
+
+func TestAuthorizeBulk_Concurrently(t *testing.T) {
+	client := new(evenAuthorizer)
+
+	allowed, err := AuthorizeBulk(context.Background(), client, userID, "ACTION", origins(20), 3)
+	require.NoError(t, err)
+
+	require.Len(t, allowed, 20)
+	require.True(t, allowed[0])
+	require.False(t, allowed[1])
+	require.LessOrEqual(t, client.maxActive, 3)
+	require.EqualValues(t, 20, client.calls.Load())
+}
+
+func TestAuthorizeBulk_ConcurrentlyFails(t *testing.T) {
+	client := &evenAuthorizer{err: errors.New("unavailable"), resourceID: "3"}
+
+	_, err := AuthorizeBulk(context.Background(), client, userID, "ACTION", origins(10), 2)
+	require.ErrorContains(t, err, "unavailable")
+}
+
+func TestAuthorizeBulk_InBatches(t *testing.T) {
+	client := new(evenBulkAuthorizer)
+
+	allowed, err := AuthorizeBulk(context.Background(), client, userID, "ACTION", origins(2500), 1)
+	require.NoError(t, err)
+
+	require.Equal(t, []int{1000, 1000, 500}, client.batches)
+	require.Len(t, allowed, 2500)
+
+	for i, ok := range allowed {
+		require.Equal(t, i%2 == 0, ok, i)
+	}
+}
+
+func TestFilterFromContext(t *testing.T) {
+	var (
+		filtered []*proto.Origin
+		err      error
+	)
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := FilterFromContext(r.Context())
+		require.True(t, ok)
+
+		filtered, err = filter.Filter(r.Context(), "ACTION", origins(5))
+	})
+
+	m := New(WithAuthorizerClient(new(evenAuthorizer)), WithBulkConcurrency(2))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request = request.WithContext(useridcontext.NewContext(request.Context(), userID))
+
+	m.Middleware()(endpoint).ServeHTTP(httptest.NewRecorder(), request)
+
+	require.NoError(t, err)
+	require.Len(t, filtered, 3)
+	require.Equal(t, []string{"0", "2", "4"}, []string{filtered[0].GetId(), filtered[1].GetId(), filtered[2].GetId()})
+}
+
+func TestFilterFromContext_Missing(t *testing.T) {
+	_, ok := FilterFromContext(context.Background())
+	require.False(t, ok)
+}
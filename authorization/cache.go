@@ -0,0 +1,393 @@
+package authorization
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	proto "github.com/SKF/proto/v2/common"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultCacheAllowTTL   = time.Minute
+	DefaultCacheDenyTTL    = 10 * time.Second
+	DefaultCacheMaxEntries = 10000
+
+	// DefaultCacheLookupTimeout bounds the lookups shared by concurrent callers,
+	// which are not canceled together with any single caller.
+	DefaultCacheLookupTimeout = 10 * time.Second
+
+	cacheKeySeparator = "\x00"
+)
+
+// CachingAuthorizerClient caches the decisions of an AuthorizerClient, keyed on
+// user, action and resource. Allowed and denied decisions are cached for
+// separate durations, errors are never cached. Identical concurrent lookups are
+// collapsed into a single call to the AuthorizerClient. Denied decisions of
+// bulk calls are only served to bulk calls, as they don't include a reason.
+type CachingAuthorizerClient struct {
+	client AuthorizerClient
+
+	allowTTL        time.Duration
+	denyTTL         time.Duration
+	maxEntries      int
+	lookupTimeout   time.Duration
+	bulkConcurrency int
+
+	group singleflight.Group
+
+	mutex      sync.Mutex
+	entries    map[cacheKey]*list.Element
+	lru        *list.List
+	generation uint64
+}
+
+var _ BulkAuthorizerClient = (*CachingAuthorizerClient)(nil)
+
+type cacheKey struct {
+	userID       string
+	action       string
+	resourceType string
+	resourceID   string
+}
+
+func newCacheKey(userID, action string, resource *proto.Origin) cacheKey {
+	return cacheKey{
+		userID:       userID,
+		action:       action,
+		resourceType: resource.GetType(),
+		resourceID:   resource.GetId(),
+	}
+}
+
+func (k cacheKey) String() string {
+	return strings.Join([]string{k.userID, k.action, k.resourceType, k.resourceID}, cacheKeySeparator)
+}
+
+type cacheEntry struct {
+	key cacheKey
+	decision
+	expires time.Time
+}
+
+type decision struct {
+	authorized bool
+	reason     string
+
+	// reasonUnknown marks denied decisions of bulk calls, which don't tell why
+	// the access was denied, e.g. because the resource doesn't exist.
+	reasonUnknown bool
+}
+
+type CacheOption func(*CachingAuthorizerClient)
+
+// WithCacheAllowTTL sets how long allowed decisions are cached.
+func WithCacheAllowTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingAuthorizerClient) {
+		c.allowTTL = ttl
+	}
+}
+
+// WithCacheDenyTTL sets how long denied decisions are cached, zero disables
+// caching of denied decisions.
+func WithCacheDenyTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingAuthorizerClient) {
+		c.denyTTL = ttl
+	}
+}
+
+// WithCacheMaxEntries bounds the number of cached decisions, the least recently
+// used decisions are evicted first.
+func WithCacheMaxEntries(maxEntries int) CacheOption {
+	return func(c *CachingAuthorizerClient) {
+		c.maxEntries = maxEntries
+	}
+}
+
+// WithCacheLookupTimeout bounds the calls to the AuthorizerClient shared by
+// concurrent callers, by default DefaultCacheLookupTimeout.
+func WithCacheLookupTimeout(timeout time.Duration) CacheOption {
+	return func(c *CachingAuthorizerClient) {
+		if timeout > 0 {
+			c.lookupTimeout = timeout
+		}
+	}
+}
+
+// WithCacheBulkConcurrency limits the number of concurrent calls made by
+// IsAuthorizedBulk to an AuthorizerClient without support for bulk calls, by
+// default DefaultBulkConcurrency. Calls made by AuthorizeBulk, e.g. by the
+// ResourceFilter, use the concurrency given to it instead.
+func WithCacheBulkConcurrency(concurrency int) CacheOption {
+	return func(c *CachingAuthorizerClient) {
+		c.bulkConcurrency = concurrency
+	}
+}
+
+// NewCachingAuthorizerClient returns client decorated with a cache, intended to
+// be passed to WithAuthorizerClient.
+func NewCachingAuthorizerClient(client AuthorizerClient, opts ...CacheOption) *CachingAuthorizerClient {
+	c := &CachingAuthorizerClient{
+		client: client,
+
+		allowTTL:        DefaultCacheAllowTTL,
+		denyTTL:         DefaultCacheDenyTTL,
+		maxEntries:      DefaultCacheMaxEntries,
+		lookupTimeout:   DefaultCacheLookupTimeout,
+		bulkConcurrency: DefaultBulkConcurrency,
+
+		entries: make(map[cacheKey]*list.Element),
+		lru:     list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *CachingAuthorizerClient) IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error) {
+	key := newCacheKey(userID, action, resource)
+
+	if entry, found := c.get(key); found && !entry.reasonUnknown {
+		return entry.authorized, entry.reason, nil
+	}
+
+	// The lookup is shared by all callers, so it must not be canceled when the
+	// first caller is. Each caller still stops waiting once it is canceled.
+	results := c.group.DoChan(key.String(), func() (any, error) {
+		generation := c.currentGeneration()
+
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.lookupTimeout)
+		defer cancel()
+
+		authorized, reason, err := c.client.IsAuthorizedWithReason(lookupCtx, userID, action, resource)
+		if err != nil {
+			return nil, err
+		}
+
+		d := decision{authorized: authorized, reason: reason}
+		c.set(key, d, generation)
+
+		return d, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return false, "", status.FromContextError(ctx.Err()).Err()
+	case result := <-results:
+		if result.Err != nil {
+			return false, "", result.Err
+		}
+
+		d := result.Val.(decision) //nolint:forcetypeassert
+
+		return d.authorized, d.reason, nil
+	}
+}
+
+// IsAuthorizedBulk serves the cached decisions and forwards the other resources
+// to the AuthorizerClient, in a single call if it is a BulkAuthorizerClient.
+// The decisions are returned in the order of the resources.
+func (c *CachingAuthorizerClient) IsAuthorizedBulk(ctx context.Context, userID, action string, resources []*proto.Origin) ([]*proto.Origin, []bool, error) {
+	allowed := make([]bool, len(resources))
+
+	var (
+		misses  []*proto.Origin
+		indexes []int
+	)
+
+	for i, resource := range resources {
+		if entry, found := c.get(newCacheKey(userID, action, resource)); found {
+			allowed[i] = entry.authorized
+			continue
+		}
+
+		misses = append(misses, resource)
+		indexes = append(indexes, i)
+	}
+
+	if len(misses) == 0 {
+		return resources, allowed, nil
+	}
+
+	fetched, err := c.lookupBulk(ctx, userID, action, misses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for j, i := range indexes {
+		allowed[i] = fetched[j]
+	}
+
+	return resources, allowed, nil
+}
+
+func (c *CachingAuthorizerClient) lookupBulk(ctx context.Context, userID, action string, resources []*proto.Origin) ([]bool, error) {
+	bulk, ok := c.client.(BulkAuthorizerClient)
+	if !ok {
+		return c.lookupConcurrently(ctx, userID, action, resources)
+	}
+
+	generation := c.currentGeneration()
+
+	returned, oks, err := bulk.IsAuthorizedBulk(ctx, userID, action, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := matchDecisions(resources, returned, oks)
+
+	for i, resource := range resources {
+		c.set(newCacheKey(userID, action, resource), decision{
+			authorized:    allowed[i],
+			reasonUnknown: !allowed[i],
+		}, generation)
+	}
+
+	return allowed, nil
+}
+
+// lookupConcurrently looks up the resources one by one, sharing the lookups
+// with concurrent callers.
+func (c *CachingAuthorizerClient) lookupConcurrently(ctx context.Context, userID, action string, resources []*proto.Origin) ([]bool, error) {
+	allowed := make([]bool, len(resources))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(max(bulkConcurrencyFromContext(ctx, c.bulkConcurrency), 1))
+
+	for i, resource := range resources {
+		group.Go(func() error {
+			ok, _, err := c.IsAuthorizedWithReason(ctx, userID, action, resource)
+			allowed[i] = ok
+
+			return err
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return allowed, nil
+}
+
+// InvalidateUser removes all cached decisions of the user.
+func (c *CachingAuthorizerClient) InvalidateUser(userID string) {
+	c.invalidate(func(key cacheKey) bool {
+		return key.userID == userID
+	})
+}
+
+// InvalidateResource removes all cached decisions on the resource.
+func (c *CachingAuthorizerClient) InvalidateResource(resource *proto.Origin) {
+	c.invalidate(func(key cacheKey) bool {
+		return key.resourceType == resource.GetType() && key.resourceID == resource.GetId()
+	})
+}
+
+// Purge removes all cached decisions.
+func (c *CachingAuthorizerClient) Purge() {
+	c.invalidate(func(cacheKey) bool {
+		return true
+	})
+}
+
+// Len returns the number of cached decisions, including expired ones which
+// have not yet been evicted.
+func (c *CachingAuthorizerClient) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *CachingAuthorizerClient) get(key cacheKey) (cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		return cacheEntry{}, false
+	}
+
+	entry := element.Value.(cacheEntry) //nolint:forcetypeassert
+	if !entry.expires.After(time.Now()) {
+		c.remove(element)
+		return cacheEntry{}, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry, true
+}
+
+// set caches the decision, unless the cache has been invalidated since the
+// lookup started at generation.
+func (c *CachingAuthorizerClient) set(key cacheKey, d decision, generation uint64) {
+	ttl := c.denyTTL
+	if d.authorized {
+		ttl = c.allowTTL
+	}
+
+	if ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	entry := cacheEntry{
+		key:      key,
+		decision: d,
+		expires:  time.Now().Add(ttl),
+	}
+
+	if element, found := c.entries[key]; found {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *CachingAuthorizerClient) invalidate(match func(cacheKey) bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+
+	for key, element := range c.entries {
+		if match(key) {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *CachingAuthorizerClient) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(cacheEntry) //nolint:forcetypeassert
+	delete(c.entries, entry.key)
+}
+
+func (c *CachingAuthorizerClient) currentGeneration() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.generation
+}
@@ -0,0 +1,291 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authorize "github.com/SKF/go-enlight-authorizer/client"
+	proto "github.com/SKF/proto/v2/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingAuthorizer struct {
+	calls      atomic.Int32
+	authorized bool
+	err        error
+	release    chan struct{}
+}
+
+func (a *countingAuthorizer) IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error) {
+	a.calls.Add(1)
+
+	if a.release != nil {
+		<-a.release
+	}
+
+	if a.authorized {
+		return true, "", a.err
+	}
+
+	return false, authorize.ReasonAccessDenied, a.err
+}
+
+func TestCache_AllowAndDenyTTL(t *testing.T) {
+	allowing := &countingAuthorizer{authorized: true}
+	cache := NewCachingAuthorizerClient(allowing, WithCacheAllowTTL(time.Hour))
+
+	for range 3 {
+		ok, _, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	require.EqualValues(t, 1, allowing.calls.Load())
+
+	denying := &countingAuthorizer{}
+	cache = NewCachingAuthorizerClient(denying, WithCacheDenyTTL(time.Nanosecond))
+
+	for range 2 {
+		ok, reason, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.Equal(t, authorize.ReasonAccessDenied, reason)
+
+		time.Sleep(time.Millisecond)
+	}
+
+	require.EqualValues(t, 2, denying.calls.Load(), "expired decisions are looked up again")
+}
+
+func TestCache_ErrorsAreNotCached(t *testing.T) {
+	failing := &countingAuthorizer{err: errors.New("unavailable")}
+	cache := NewCachingAuthorizerClient(failing)
+
+	for range 2 {
+		_, _, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)
+		require.Error(t, err)
+	}
+
+	require.EqualValues(t, 2, failing.calls.Load())
+	require.Zero(t, cache.Len())
+}
+
+func TestCache_KeyedOnUserActionAndResource(t *testing.T) {
+	allowing := &countingAuthorizer{authorized: true}
+	cache := NewCachingAuthorizerClient(allowing)
+
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)                                  //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), "other-user", "ACTION", resource)                            //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), userID, "OTHER_ACTION", resource)                            //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", &proto.Origin{Id: resource.Id, Type: "x"}) //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", nil)                                       //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)                                  //nolint:errcheck
+
+	require.EqualValues(t, 5, allowing.calls.Load())
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	allowing := &countingAuthorizer{authorized: true}
+	cache := NewCachingAuthorizerClient(allowing, WithCacheMaxEntries(2))
+
+	lookup := func(action string) {
+		cache.IsAuthorizedWithReason(context.Background(), userID, action, resource) //nolint:errcheck
+	}
+
+	lookup("A")
+	lookup("B")
+	lookup("A") // A is now the most recently used
+	lookup("C") // evicts B
+
+	require.Equal(t, 2, cache.Len())
+	require.EqualValues(t, 3, allowing.calls.Load())
+
+	lookup("A")
+	require.EqualValues(t, 3, allowing.calls.Load(), "A is still cached")
+
+	lookup("B")
+	require.EqualValues(t, 4, allowing.calls.Load(), "B was evicted")
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	blocking := &countingAuthorizer{authorized: true, release: make(chan struct{})}
+	cache := NewCachingAuthorizerClient(blocking)
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, _, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return blocking.calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(blocking.release)
+	wg.Wait()
+
+	require.EqualValues(t, 1, blocking.calls.Load())
+}
+
+func TestCache_CanceledCaller(t *testing.T) {
+	blocking := &countingAuthorizer{authorized: true, release: make(chan struct{})}
+	cache := NewCachingAuthorizerClient(blocking)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := cache.IsAuthorizedWithReason(ctx, userID, "ACTION", resource)
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	close(blocking.release)
+
+	require.Eventually(t, func() bool { return cache.Len() == 1 }, time.Second, time.Millisecond, "the shared lookup completes")
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	allowing := &countingAuthorizer{authorized: true}
+	cache := NewCachingAuthorizerClient(allowing)
+
+	other := &proto.Origin{Id: "other", Type: "node"}
+
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)       //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", other)          //nolint:errcheck
+	cache.IsAuthorizedWithReason(context.Background(), "other-user", "ACTION", resource) //nolint:errcheck
+	require.Equal(t, 3, cache.Len())
+
+	cache.InvalidateResource(resource)
+	require.Equal(t, 1, cache.Len())
+
+	cache.InvalidateUser(userID)
+	require.Zero(t, cache.Len())
+
+	cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource) //nolint:errcheck
+	cache.Purge()
+	require.Zero(t, cache.Len())
+}
+
+func TestCache_InvalidationDuringLookup(t *testing.T) {
+	blocking := &countingAuthorizer{authorized: true, release: make(chan struct{})}
+	cache := NewCachingAuthorizerClient(blocking)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource) //nolint:errcheck
+	}()
+
+	require.Eventually(t, func() bool { return blocking.calls.Load() == 1 }, time.Second, time.Millisecond)
+	cache.InvalidateUser(userID)
+	close(blocking.release)
+	<-done
+
+	require.Zero(t, cache.Len(), "decisions looked up before the invalidation are not cached")
+}
+
+type hangingAuthorizer struct {
+	calls atomic.Int32
+}
+
+func (a *hangingAuthorizer) IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error) {
+	a.calls.Add(1)
+	<-ctx.Done()
+
+	return false, "", status.FromContextError(ctx.Err()).Err()
+}
+
+func TestCache_LookupTimeout(t *testing.T) {
+	hanging := new(hangingAuthorizer)
+	cache := NewCachingAuthorizerClient(hanging, WithCacheLookupTimeout(10*time.Millisecond))
+
+	for range 2 {
+		_, _, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resource)
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	}
+
+	require.EqualValues(t, 2, hanging.calls.Load(), "a timed out lookup is not shared with later callers")
+}
+
+func TestCache_Bulk(t *testing.T) {
+	client := new(evenBulkAuthorizer)
+	cache := NewCachingAuthorizerClient(client)
+
+	allowed, err := AuthorizeBulk(context.Background(), cache, userID, "ACTION", origins(10), 1)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, true, false, true, false, true, false, true, false}, allowed)
+
+	allowed, err = AuthorizeBulk(context.Background(), cache, userID, "ACTION", origins(12), 1)
+	require.NoError(t, err)
+	require.Len(t, allowed, 12)
+	require.True(t, allowed[10])
+	require.False(t, allowed[11])
+
+	require.Equal(t, []int{10, 2}, client.batches, "only the misses are forwarded")
+	require.Zero(t, client.calls.Load())
+}
+
+func TestCache_BulkDenialsWithoutReason(t *testing.T) {
+	client := new(evenBulkAuthorizer)
+	cache := NewCachingAuthorizerClient(client)
+
+	resources := origins(2)
+
+	_, err := AuthorizeBulk(context.Background(), cache, userID, "ACTION", resources, 1)
+	require.NoError(t, err)
+
+	ok, _, err := cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resources[0])
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, client.calls.Load(), "allowed decisions of bulk calls are served")
+
+	ok, _, err = cache.IsAuthorizedWithReason(context.Background(), userID, "ACTION", resources[1])
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.EqualValues(t, 1, client.calls.Load(), "denied decisions of bulk calls have no reason")
+
+	_, err = AuthorizeBulk(context.Background(), cache, userID, "ACTION", resources, 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, client.batches)
+}
+
+func TestCache_BulkConcurrency(t *testing.T) {
+	client := new(evenAuthorizer)
+	cache := NewCachingAuthorizerClient(client)
+
+	_, err := AuthorizeBulk(context.Background(), cache, userID, "ACTION", origins(20), 3)
+	require.NoError(t, err)
+	require.LessOrEqual(t, client.maxActive, 3, "the concurrency of AuthorizeBulk is used")
+
+	client = new(evenAuthorizer)
+	cache = NewCachingAuthorizerClient(client, WithCacheBulkConcurrency(2))
+
+	_, _, err = cache.IsAuthorizedBulk(context.Background(), userID, "ACTION", origins(20))
+	require.NoError(t, err)
+	require.LessOrEqual(t, client.maxActive, 2)
+	require.EqualValues(t, 20, client.calls.Load())
+}
+
+func TestCache_BulkWithoutBulkClient(t *testing.T) {
+	client := new(evenAuthorizer)
+	cache := NewCachingAuthorizerClient(client)
+
+	for range 2 {
+		allowed, err := AuthorizeBulk(context.Background(), cache, userID, "ACTION", origins(5), 1)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false, true, false, true}, allowed)
+	}
+
+	require.EqualValues(t, 5, client.calls.Load())
+}
@@ -0,0 +1,229 @@
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/SKF/go-utility/v2/uuid"
+	proto "github.com/SKF/proto/v2/common"
+	"github.com/gorilla/mux"
+
+	custom_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
+)
+
+// DefaultMaxBodySize is the largest body read by FromJSONBody, unless changed
+// through WithMaxBodySize.
+const DefaultMaxBodySize = 1 << 20
+
+// Locations of resource identifiers, used by the problems of the extractors.
+const (
+	LocationPath   = "path"
+	LocationQuery  = "query"
+	LocationHeader = "header"
+	LocationBody   = "body"
+)
+
+type extractorConfig struct {
+	validate    func(string) bool
+	maxBodySize int64
+}
+
+type ExtractorOption func(*extractorConfig)
+
+// WithIdentifierValidator replaces the default validation of the identifiers,
+// which requires them to be UUIDs.
+func WithIdentifierValidator(validate func(id string) bool) ExtractorOption {
+	return func(c *extractorConfig) {
+		c.validate = validate
+	}
+}
+
+// WithAnyIdentifier accepts any non-empty identifier.
+func WithAnyIdentifier() ExtractorOption {
+	return WithIdentifierValidator(func(string) bool { return true })
+}
+
+// WithMaxBodySize limits the size of the body read by FromJSONBody, larger
+// bodies are rejected with a RequestBodyTooLargeProblem.
+func WithMaxBodySize(limit int64) ExtractorOption {
+	return func(c *extractorConfig) {
+		c.maxBodySize = limit
+	}
+}
+
+// FromPathVar extracts the resource from a path variable, e.g. "nodeId" of the
+// route "/nodes/{nodeId}". Both gorilla/mux variables and the path values of
+// http.ServeMux are supported.
+func FromPathVar(name, resourceType string, opts ...ExtractorOption) ResourceExtractor {
+	return newExtractor(LocationPath, name, resourceType, opts, func(_ context.Context, r *http.Request, _ extractorConfig) (string, error) {
+		if id, found := mux.Vars(r)[name]; found {
+			return id, nil
+		}
+
+		return r.PathValue(name), nil
+	})
+}
+
+// FromQuery extracts the resource from a query parameter.
+func FromQuery(name, resourceType string, opts ...ExtractorOption) ResourceExtractor {
+	return newExtractor(LocationQuery, name, resourceType, opts, func(_ context.Context, r *http.Request, _ extractorConfig) (string, error) {
+		return r.URL.Query().Get(name), nil
+	})
+}
+
+// FromHeader extracts the resource from a request header.
+func FromHeader(name, resourceType string, opts ...ExtractorOption) ResourceExtractor {
+	return newExtractor(LocationHeader, name, resourceType, opts, func(_ context.Context, r *http.Request, _ extractorConfig) (string, error) {
+		return r.Header.Get(name), nil
+	})
+}
+
+// FromJSONBody extracts the resource from the JSON body using a RFC 6901 JSON
+// pointer, e.g. "/asset/id". The body is left unconsumed for the handler. At
+// most DefaultMaxBodySize bytes are read, see WithMaxBodySize.
+func FromJSONBody(pointer, resourceType string, opts ...ExtractorOption) ResourceExtractor {
+	return newExtractor(LocationBody, pointer, resourceType, opts, func(ctx context.Context, r *http.Request, config extractorConfig) (string, error) {
+		body, err := readBody(ctx, r, config.maxBodySize)
+		if err != nil {
+			return "", err
+		}
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			return "", nil
+		}
+
+		var document any
+		if err := json.Unmarshal(body, &document); err != nil {
+			return "", custom_problems.MalformedResourceIdentifier(LocationBody, pointer, resourceType)
+		}
+
+		value, found := resolvePointer(document, pointer)
+		if !found || value == nil {
+			return "", nil
+		}
+
+		id, ok := value.(string)
+		if !ok {
+			return "", custom_problems.MalformedResourceIdentifier(LocationBody, pointer, resourceType)
+		}
+
+		return id, nil
+	})
+}
+
+func newExtractor(location, name, resourceType string, opts []ExtractorOption, extract func(context.Context, *http.Request, extractorConfig) (string, error)) ResourceExtractor {
+	config := extractorConfig{validate: uuid.IsValid, maxBodySize: DefaultMaxBodySize}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(ctx context.Context, r *http.Request) (*proto.Origin, error) {
+		id, err := extract(ctx, r, config)
+		if err != nil {
+			return nil, err
+		}
+
+		if id == "" {
+			return nil, custom_problems.MissingResourceIdentifier(location, name, resourceType)
+		}
+
+		if !config.validate(id) {
+			return nil, custom_problems.MalformedResourceIdentifier(location, name, resourceType)
+		}
+
+		return &proto.Origin{Id: id, Type: resourceType}, nil
+	}
+}
+
+// resolvePointer returns the value of the document referenced by the JSON pointer.
+func resolvePointer(document any, pointer string) (any, bool) {
+	if pointer == "" {
+		return document, true
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	current := document
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		switch value := current.(type) {
+		case map[string]any:
+			next, found := value[token]
+			if !found {
+				return nil, false
+			}
+
+			current = next
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, false
+			}
+
+			current = value[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+type bodyCache struct {
+	once sync.Once
+	body []byte
+	err  error
+}
+
+type bodyCacheContextKey struct{}
+
+// withBodyCache makes the policies, which are evaluated concurrently, share a
+// single read of the request body.
+func withBodyCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bodyCacheContextKey{}, new(bodyCache))
+}
+
+// readBody returns the request body and replaces it with an unread copy. Bodies
+// larger than limit bytes are rejected.
+func readBody(ctx context.Context, r *http.Request, limit int64) ([]byte, error) {
+	cache, ok := ctx.Value(bodyCacheContextKey{}).(*bodyCache)
+	if !ok {
+		cache = new(bodyCache)
+	}
+
+	cache.once.Do(func() {
+		if r.Body == nil || r.Body == http.NoBody {
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, limit))
+		r.Body.Close()
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var tooLarge *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &tooLarge):
+			cache.err = custom_problems.RequestBodyTooLarge(limit)
+		case err != nil:
+			cache.err = custom_problems.UnreadableRequestBody()
+		default:
+			cache.body = body
+		}
+	})
+
+	return cache.body, cache.err
+}
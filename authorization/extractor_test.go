@@ -0,0 +1,197 @@
+package authorization
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authorize_mock "github.com/SKF/go-enlight-authorizer/mock"
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+const nodeID = "904dfc2a-7103-4561-aa45-6e5d317e90eb"
+
+func requireProblem(t *testing.T, err error, problemType string) {
+	t.Helper()
+
+	require.Error(t, err)
+
+	problem := problems.FromError(err)
+	require.Equal(t, problemType, problem.ProblemType())
+	require.Equal(t, http.StatusBadRequest, problem.ProblemStatus())
+}
+
+func TestFromPathVar_Gorilla(t *testing.T) {
+	extractor := FromPathVar("nodeId", "node")
+
+	router := mux.NewRouter()
+	router.Path("/nodes/{nodeId}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin, err := extractor(r.Context(), r)
+		if err != nil {
+			problems.WriteResponse(r.Context(), err, w, r)
+			return
+		}
+
+		w.Write([]byte(origin.Type + ":" + origin.Id)) //nolint:errcheck
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nodes/"+nodeID, nil))
+	require.Equal(t, "node:"+nodeID, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nodes/not-a-uuid", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFromPathVar_ServeMux(t *testing.T) {
+	var err error
+
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc("GET /nodes/{nodeId}", func(w http.ResponseWriter, r *http.Request) {
+		_, err = FromPathVar("nodeId", "node")(r.Context(), r)
+	})
+	serveMux.HandleFunc("GET /nodes", func(w http.ResponseWriter, r *http.Request) {
+		_, err = FromPathVar("nodeId", "node")(r.Context(), r)
+	})
+
+	serveMux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/"+nodeID, nil))
+	require.NoError(t, err)
+
+	serveMux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes", nil))
+	requireProblem(t, err, "/problems/missing-resource-identifier")
+}
+
+func TestFromQueryAndHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?nodeId="+nodeID+"&name=pump", nil)
+	r.Header.Set("X-Node-ID", nodeID)
+
+	origin, err := FromQuery("nodeId", "node")(r.Context(), r)
+	require.NoError(t, err)
+	require.Equal(t, nodeID, origin.Id)
+	require.Equal(t, "node", origin.Type)
+
+	origin, err = FromHeader("X-Node-ID", "node")(r.Context(), r)
+	require.NoError(t, err)
+	require.Equal(t, nodeID, origin.Id)
+
+	_, err = FromQuery("name", "node")(r.Context(), r)
+	requireProblem(t, err, "/problems/malformed-resource-identifier")
+
+	origin, err = FromQuery("name", "node", WithAnyIdentifier())(r.Context(), r)
+	require.NoError(t, err)
+	require.Equal(t, "pump", origin.Id)
+
+	_, err = FromHeader("X-Missing", "node")(r.Context(), r)
+	requireProblem(t, err, "/problems/missing-resource-identifier")
+}
+
+func TestFromJSONBody(t *testing.T) {
+	body := `{"asset": {"id": "` + nodeID + `", "tags": ["a/b"]}, "a/b": {"~id": "` + nodeID + `"}, "count": 1}`
+
+	testCases := []struct {
+		pointer string
+		problem string
+	}{
+		{pointer: "/asset/id"},
+		{pointer: "/a~1b/~0id"},
+		{pointer: "/asset/tags/0", problem: "/problems/malformed-resource-identifier"},
+		{pointer: "/count", problem: "/problems/malformed-resource-identifier"},
+		{pointer: "/asset/missing", problem: "/problems/missing-resource-identifier"},
+		{pointer: "/asset/tags/5", problem: "/problems/missing-resource-identifier"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.pointer, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+			origin, err := FromJSONBody(tC.pointer, "node")(r.Context(), r)
+			if tC.problem != "" {
+				requireProblem(t, err, tC.problem)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, nodeID, origin.Id)
+			}
+
+			unconsumed, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, body, string(unconsumed), "the body is left for the handler")
+		})
+	}
+}
+
+func TestFromJSONBody_InvalidBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":`))
+	_, err := FromJSONBody("/id", "node")(r.Context(), r)
+	requireProblem(t, err, "/problems/malformed-resource-identifier")
+
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	_, err = FromJSONBody("/id", "node")(r.Context(), r)
+	requireProblem(t, err, "/problems/missing-resource-identifier")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestFromJSONBody_BodySize(t *testing.T) {
+	body := `{"id": "` + nodeID + `"}`
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	_, err := FromJSONBody("/id", "node", WithMaxBodySize(int64(len(body)-1)))(r.Context(), r)
+	require.Error(t, err)
+
+	problem := problems.FromError(err)
+	require.Equal(t, "/problems/request-body-too-large", problem.ProblemType())
+	require.Equal(t, http.StatusRequestEntityTooLarge, problem.ProblemStatus())
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	origin, err := FromJSONBody("/id", "node", WithMaxBodySize(int64(len(body))))(r.Context(), r)
+	require.NoError(t, err)
+	require.Equal(t, nodeID, origin.Id)
+}
+
+func TestFromJSONBody_UnreadableBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", failingReader{})
+	_, err := FromJSONBody("/id", "node")(r.Context(), r)
+	requireProblem(t, err, "/problems/unreadable-request-body")
+}
+
+func TestFromJSONBody_ConcurrentPolicies(t *testing.T) {
+	body := `{"parent": "` + nodeID + `", "child": "` + nodeID + `"}`
+
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, mock.Anything, mock.Anything).Return(true, "", nil)
+
+	m := New(WithAuthorizerClient(authorizerMock))
+	m.SetPolicyMatching(route.PathPrefix("/"), MultiPolicy{
+		ActionResourcePolicy{Action: "PARENT", ResourceExtractor: FromJSONBody("/parent", "node")},
+		ActionResourcePolicy{Action: "CHILD", ResourceExtractor: FromJSONBody("/child", "node")},
+	})
+
+	var unconsumed []byte
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unconsumed, _ = io.ReadAll(r.Body)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r = r.WithContext(useridcontext.NewContext(r.Context(), userID))
+	w := httptest.NewRecorder()
+
+	m.Middleware()(endpoint).ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, body, string(unconsumed), "the body is left for the handler")
+	authorizerMock.AssertNumberOfCalls(t, "IsAuthorizedWithReason", 2)
+}
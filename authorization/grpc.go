@@ -0,0 +1,69 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/grpcstatus"
+	"github.com/SKF/go-enlight-middleware/internal/grpcutil"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+// SetMethodPolicy enforces the policy on gRPC calls to the full method name,
+// e.g. "/hierarchy.Hierarchy/GetNode".
+func (m *Middleware) SetMethodPolicy(fullMethod string, policy Policy) *Middleware {
+	return m.SetPolicyMatching(route.Path(fullMethod), policy)
+}
+
+type messageContextKey struct{}
+
+// MessageFromContext returns the request message of unary gRPC calls, intended
+// to be used by ResourceExtractors.
+func MessageFromContext(ctx context.Context) (any, bool) {
+	message := ctx.Value(messageContextKey{})
+	return message, message != nil
+}
+
+// UnaryServerInterceptor enforces the policies set through SetMethodPolicy on gRPC calls.
+func (m *Middleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	m.warnIfDisabled()
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := m.intercept(context.WithValue(ctx, messageContextKey{}, req), info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(m.withResourceFilter(ctx), req)
+	}
+}
+
+// StreamServerInterceptor enforces the policies set through SetMethodPolicy on gRPC streams.
+func (m *Middleware) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	m.warnIfDisabled()
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := m.intercept(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, grpcutil.WithContext(ss, m.withResourceFilter(ss.Context())))
+	}
+}
+
+func (m *Middleware) intercept(ctx context.Context, fullMethod string) error {
+	spanCtx, span := m.Tracer.StartSpan(ctx, "Authorization")
+	defer span.End()
+
+	if err := m.authorize(spanCtx, grpcutil.Request(ctx, fullMethod)); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			middleware.RecordProblem(span, err)
+		}
+
+		return grpcstatus.Error(err)
+	}
+
+	return nil
+}
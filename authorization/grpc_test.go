@@ -0,0 +1,94 @@
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	authorize "github.com/SKF/go-enlight-authorizer/client"
+	authorize_mock "github.com/SKF/go-enlight-authorizer/mock"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	proto "github.com/SKF/proto/v2/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	missing := &proto.Origin{Id: "9ab3bbab-3b1e-4c43-a5b4-7ab9f3f1e6e4", Type: "node"}
+
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, resource).Return(true, "", nil)
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, missing).Return(false, authorize.ReasonResourceNotFound, nil)
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, "HIERARCHY::DELETE_NODE", (*proto.Origin)(nil)).Return(false, authorize.ReasonAccessDenied, nil)
+
+	messagePolicy := ActionResourcePolicy{
+		Action: policy.Action,
+		ResourceExtractor: func(ctx context.Context, r *http.Request) (*proto.Origin, error) {
+			message, ok := MessageFromContext(ctx)
+			require.True(t, ok)
+
+			return message.(*proto.Origin), nil //nolint:forcetypeassert
+		},
+	}
+
+	interceptor := New(WithAuthorizerClient(authorizerMock)).
+		SetMethodPolicy("/hierarchy.Hierarchy/GetNode", messagePolicy).
+		SetMethodPolicy("/hierarchy.Hierarchy/DeleteNode", ActionResourcePolicy{Action: "HIERARCHY::DELETE_NODE"}).
+		UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return req, nil
+	}
+
+	testCases := []struct {
+		desc     string
+		method   string
+		message  *proto.Origin
+		expected codes.Code
+	}{
+		{desc: "authorized", method: "/hierarchy.Hierarchy/GetNode", message: resource, expected: codes.OK},
+		{desc: "missing resource", method: "/hierarchy.Hierarchy/GetNode", message: missing, expected: codes.NotFound},
+		{desc: "unauthorized", method: "/hierarchy.Hierarchy/DeleteNode", expected: codes.PermissionDenied},
+		{desc: "without policy", method: "/hierarchy.Hierarchy/ListNodes", expected: codes.OK},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ctx := useridcontext.NewContext(context.Background(), userID)
+
+			_, err := interceptor(ctx, tC.message, &grpc.UnaryServerInfo{FullMethod: tC.method}, handler)
+			require.Equal(t, tC.expected, status.Code(err), err)
+		})
+	}
+
+	_, err := interceptor(context.Background(), resource, &grpc.UnaryServerInfo{FullMethod: "/hierarchy.Hierarchy/GetNode"}, handler)
+	require.Equal(t, codes.Internal, status.Code(err), "missing authentication")
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, "HIERARCHY::WATCH_NODES", (*proto.Origin)(nil)).Return(false, authorize.ReasonAccessDenied, nil)
+
+	interceptor := New(WithAuthorizerClient(authorizerMock)).
+		SetMethodPolicy("/hierarchy.Hierarchy/WatchNodes", ActionResourcePolicy{Action: "HIERARCHY::WATCH_NODES"}).
+		StreamServerInterceptor()
+
+	stream := serverStream{ctx: useridcontext.NewContext(context.Background(), userID)}
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/hierarchy.Hierarchy/WatchNodes"}, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/SKF/go-rest-utility/problems"
 	"github.com/SKF/go-utility/v2/log"
@@ -12,17 +13,23 @@ import (
 	"github.com/gorilla/mux"
 
 	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 type AuthorizerClient interface {
 	IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error)
 }
 
+const metricsName = "authorization"
+
 type Middleware struct {
-	Tracer middleware.Tracer
+	Tracer  middleware.Tracer
+	Metrics middleware.Metrics
 
 	authorizerClient AuthorizerClient
-	policies         map[*mux.Route]Policy
+	policies         *route.Table[Policy]
+	bulkConcurrency  int
 }
 
 var (
@@ -31,10 +38,12 @@ var (
 
 func New(opts ...Option) *Middleware {
 	m := &Middleware{
-		Tracer: middleware.DefaultTracer,
+		Tracer:  middleware.DefaultTracer,
+		Metrics: middleware.DefaultMetrics,
 
 		authorizerClient: nil,
-		policies:         map[*mux.Route]Policy{},
+		policies:         new(route.Table[Policy]),
+		bulkConcurrency:  DefaultBulkConcurrency,
 	}
 
 	for _, opt := range opts {
@@ -44,60 +53,120 @@ func New(opts ...Option) *Middleware {
 	return m
 }
 
-func (m *Middleware) SetPolicy(route *mux.Route, policy Policy) *Middleware {
-	m.policies[route] = policy
+func (m *Middleware) SetPolicy(r *mux.Route, policy Policy) *Middleware {
+	return m.SetPolicyMatching(route.Mux(r), policy)
+}
+
+// SetPolicyMatching enforces the policy on all requests matched by matcher. If
+// several matchers match a request, the policy set first is used.
+func (m *Middleware) SetPolicyMatching(matcher route.Matcher, policy Policy) *Middleware {
+	m.policies.Set(matcher, policy)
 
 	return m
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageAuthorization
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
-	if m.authorizerClient == nil {
-		log.Warning("Unable no AuthorizerClient found in Authorization middleware, disabling authorization.")
-	}
+	m.warnIfDisabled()
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, span := m.Tracer.StartSpan(r.Context(), "Authorization")
 
-			policy, found := m.findPolicyForRequest(ctx, r)
-			if found && m.authorizerClient != nil {
-				userID, ok := useridcontext.FromContext(ctx)
-				if !ok {
-					err := ErrNoAuthenticationMiddleware
-
+			if err := m.authorize(ctx, r); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					middleware.RecordProblem(span, err)
 					problems.WriteResponse(ctx, err, w, r)
-					span.End()
-
-					return
 				}
 
-				if err := policy.Authorize(ctx, userID, m.authorizerClient, r); err != nil {
-					if !errors.Is(err, context.Canceled) {
-						problems.WriteResponse(ctx, err, w, r)
-					}
-
-					span.End()
+				span.End()
 
-					return
-				}
+				return
 			}
 
 			span.End()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(m.withResourceFilter(r.Context())))
 		})
 	}
 }
 
+func (m *Middleware) warnIfDisabled() {
+	if m.authorizerClient == nil {
+		log.Warning("No AuthorizerClient found in Authorization middleware, only local policies are enforced.")
+	}
+}
+
+// authorize enforces the policy of the request, if any.
+func (m *Middleware) authorize(ctx context.Context, r *http.Request) error {
+	policy, found := m.findPolicyForRequest(ctx, r)
+	if !found {
+		metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeSkipped)
+		return nil
+	}
+
+	userID, ok := useridcontext.FromContext(ctx)
+
+	var authorizer AuthorizerClient
+
+	if m.authorizerClient != nil {
+		if !ok {
+			metrics.CountRequest(m.Metrics, metricsName, r, ErrNoAuthenticationMiddleware)
+			return ErrNoAuthenticationMiddleware
+		}
+
+		authorizer = timedAuthorizer{AuthorizerClient: m.authorizerClient, metrics: m.Metrics, r: r}
+	} else if policy, found = localPolicy(policy); !found {
+		// Without an AuthorizerClient only the local policies, e.g. RequireRole,
+		// are enforced, and no resources are extracted.
+		metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeSkipped)
+		return nil
+	}
+
+	err := policy.Authorize(withBodyCache(ctx), userID, authorizer, r)
+	metrics.CountRequest(m.Metrics, metricsName, r, err)
+
+	return err
+}
+
+// withResourceFilter embeds the ResourceFilter of the authenticated user, if any.
+func (m *Middleware) withResourceFilter(ctx context.Context) context.Context {
+	if m.authorizerClient == nil {
+		return ctx
+	}
+
+	userID, ok := useridcontext.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return newFilterContext(ctx, ResourceFilter{
+		client:      m.authorizerClient,
+		userID:      userID,
+		concurrency: m.bulkConcurrency,
+	})
+}
+
 func (m *Middleware) findPolicyForRequest(ctx context.Context, r *http.Request) (Policy, bool) {
 	_, span := m.Tracer.StartSpan(ctx, "Authorization/findPolicyForRequest")
 	defer span.End()
 
-	currentRoute := mux.CurrentRoute(r)
-	if currentRoute == nil {
-		return nil, false
-	}
+	return m.policies.Lookup(r)
+}
+
+// timedAuthorizer records the latency of the calls to the AuthorizerClient.
+type timedAuthorizer struct {
+	AuthorizerClient
+
+	metrics middleware.Metrics
+	r       *http.Request
+}
 
-	policy, found := m.policies[currentRoute]
+func (a timedAuthorizer) IsAuthorizedWithReason(ctx context.Context, userID, action string, resource *proto.Origin) (bool, string, error) {
+	defer metrics.ObserveSince(a.metrics, metricsName, middleware.OperationAuthorizer, a.r, time.Now())
 
-	return policy, found
+	return a.AuthorizerClient.IsAuthorizedWithReason(ctx, userID, action, resource)
 }
@@ -15,6 +15,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 var (
@@ -105,3 +108,85 @@ func TestUnauthorizedRequestOnMissingResource(t *testing.T) {
 	require.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
 	require.Equal(t, "/problems/resource-not-found", problem.ProblemType())
 }
+
+func TestPolicyMatchingWithoutRouter(t *testing.T) {
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, resource).Return(false, authorize.ReasonAccessDenied, nil)
+
+	middleware := New(WithAuthorizerClient(authorizerMock)).
+		SetPolicyMatching(route.All{route.Path("/nodes/{nodeId}"), route.Methods(http.MethodGet)}, policy)
+
+	handler := middleware.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for path, expected := range map[string]int{
+		"/nodes/" + resource.Id: http.StatusForbidden,
+		"/assets":               http.StatusOK,
+	} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request = request.WithContext(useridcontext.NewContext(request.Context(), userID))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+
+		require.Equal(t, expected, w.Code, path)
+	}
+}
+
+func TestPolicyOnServeMux(t *testing.T) {
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, resource).Return(false, authorize.ReasonAccessDenied, nil)
+
+	middleware := New(WithAuthorizerClient(authorizerMock)).
+		SetPolicyMatching(route.Pattern("GET /nodes/{nodeId}"), policy)
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	wrappingHandlers := http.NewServeMux()
+	wrappingHandlers.Handle("GET /nodes/{nodeId}", middleware.Middleware()(endpoint))
+	wrappingHandlers.Handle("GET /assets", middleware.Middleware()(endpoint))
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /nodes/{nodeId}", endpoint)
+	serveMux.Handle("GET /assets", endpoint)
+
+	wrappingMux := route.Middleware(route.ServeMux(serveMux))(middleware.Middleware()(serveMux))
+
+	for name, handler := range map[string]http.Handler{
+		"wrapping handlers": wrappingHandlers,
+		"wrapping mux":      wrappingMux,
+	} {
+		for path, expected := range map[string]int{
+			"/nodes/" + resource.Id: http.StatusForbidden,
+			"/assets":               http.StatusOK,
+		} {
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request = request.WithContext(useridcontext.NewContext(request.Context(), userID))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+
+			require.Equal(t, expected, w.Code, name+" "+path)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, resource).Return(false, authorize.ReasonAccessDenied, nil)
+
+	recorder := new(metrics.Recorder)
+
+	middleware := New(WithAuthorizerClient(authorizerMock))
+	middleware.Metrics = recorder
+
+	response := setupAndDoRequest(userID, policy, middleware)
+	defer response.Body.Close()
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "authorization", Route: "/", Outcome: metrics.OutcomeRejected, ProblemType: "/problems/unauthorized-resource"},
+	}, recorder.Requests())
+
+	require.Equal(t, []metrics.Duration{
+		{Middleware: "authorization", Operation: metrics.OperationAuthorizer, Route: "/"},
+	}, recorder.Durations())
+}
@@ -0,0 +1,139 @@
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	proto "github.com/SKF/proto/v2/common"
+
+	"github.com/SKF/go-enlight-middleware/authentication"
+	custom_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
+)
+
+// ClaimsPolicy decides locally from the claims of the verified token, without
+// calling the AuthorizerClient. It requires the authentication middleware.
+type ClaimsPolicy func(ctx context.Context, claims authentication.Claims, r *http.Request) error
+
+func (p ClaimsPolicy) Authorize(ctx context.Context, userID string, _ AuthorizerClient, r *http.Request) error {
+	claims, ok := authentication.ClaimsFromContext(ctx)
+	if !ok {
+		return ErrNoAuthenticationMiddleware
+	}
+
+	return p(ctx, claims, r)
+}
+
+// localPolicy returns the part of policy which is decided locally, i.e. its
+// ClaimsPolicy policies, or false if nothing is left to enforce. Any other
+// policy, e.g. ActionResourcePolicy, is considered to authorize the request.
+func localPolicy(policy Policy) (Policy, bool) {
+	switch policy := policy.(type) {
+	case ClaimsPolicy:
+		return policy, true
+	case MultiPolicy:
+		local := localPolicies(policy)
+		return MultiPolicy(local), len(local) > 0
+	case AllOf:
+		local := localPolicies(policy)
+		return AllOf(local), len(local) > 0
+	case AnyOf:
+		local := make(AnyOf, 0, len(policy))
+
+		for _, p := range policy {
+			p, ok := localPolicy(p)
+			if !ok {
+				return nil, false
+			}
+
+			local = append(local, p)
+		}
+
+		return local, len(local) > 0
+	}
+
+	return nil, false
+}
+
+func localPolicies(policies []Policy) []Policy {
+	local := make([]Policy, 0, len(policies))
+
+	for _, p := range policies {
+		if p, ok := localPolicy(p); ok {
+			local = append(local, p)
+		}
+	}
+
+	return local
+}
+
+// RequireRole requires the "enlightRoles" claim to contain at least one of the roles.
+func RequireRole(roles ...string) Policy {
+	return ClaimsPolicy(func(_ context.Context, claims authentication.Claims, _ *http.Request) error {
+		if slices.ContainsFunc(claims.EnlightRoles(), func(role string) bool {
+			return slices.Contains(roles, role)
+		}) {
+			return nil
+		}
+
+		return unauthorized(claims, "REQUIRE_ROLE::"+strings.Join(roles, "|"), nil)
+	})
+}
+
+// RequireClaimEquals requires the claim to equal value, or for claims with many
+// values, e.g. "aud" or "cognito:groups", to contain value.
+func RequireClaimEquals(claim, value string) Policy {
+	return ClaimsPolicy(func(_ context.Context, claims authentication.Claims, _ *http.Request) error {
+		actual, _ := claims.Claim(claim)
+
+		switch actual := actual.(type) {
+		case string:
+			if actual == value {
+				return nil
+			}
+		case []any:
+			if slices.Contains(actual, any(value)) {
+				return nil
+			}
+		}
+
+		return unauthorized(claims, "REQUIRE_CLAIM::"+claim, nil)
+	})
+}
+
+// RequireSameCompany requires the "enlightCompanyId" claim to equal the id of
+// the company extracted from the request, e.g. FromPathVar("companyId", "company").
+func RequireSameCompany(extractor ResourceExtractor) Policy {
+	return ClaimsPolicy(func(ctx context.Context, claims authentication.Claims, r *http.Request) error {
+		company, err := extractor(ctx, r)
+		if err != nil {
+			return err
+		}
+
+		if companyID := claims.EnlightCompanyID(); companyID != "" && strings.EqualFold(companyID, company.GetId()) {
+			return nil
+		}
+
+		return unauthorized(claims, "REQUIRE_SAME_COMPANY", company)
+	})
+}
+
+// RequireTokenUse requires the token to be of the given use, i.e. an ID or an access token.
+func RequireTokenUse(use authentication.TokenUse) Policy {
+	return ClaimsPolicy(func(_ context.Context, claims authentication.Claims, _ *http.Request) error {
+		if claims.TokenUse() == use {
+			return nil
+		}
+
+		return unauthorized(claims, "REQUIRE_TOKEN_USE::"+string(use), nil)
+	})
+}
+
+func unauthorized(claims authentication.Claims, action string, resource *proto.Origin) error {
+	return custom_problems.Unauthorized(claims.UserID(), custom_problems.PolicyViolation{
+		Action:       action,
+		Resource:     resource.GetId(),
+		ResourceType: resource.GetType(),
+	})
+}
@@ -0,0 +1,133 @@
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authorize_mock "github.com/SKF/go-enlight-authorizer/mock"
+	"github.com/SKF/go-utility/v2/jwt"
+	"github.com/SKF/go-utility/v2/useridcontext"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/authentication"
+	custom_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+const companyID = "0a2b6f2c-5cf8-4a1c-9e5e-3f2b35a1d7a1"
+
+func claimsContext() context.Context {
+	claims := jwt.Claims{}
+	claims.TokenUse = jwt.TokenUseAccess
+	claims.Audience = []string{"portal"}
+	claims.CognitoGroups = []string{"enlightUserId:" + userID}
+	claims.EnlightCompanyID = companyID
+	claims.EnlightRoles = "user, admin"
+
+	ctx := useridcontext.NewContext(context.Background(), userID)
+
+	return authentication.NewClaims(claims, "token").EmbedIntoContext(ctx)
+}
+
+func authorizeLocally(t *testing.T, policy Policy, r *http.Request) error {
+	t.Helper()
+
+	if r == nil {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+	}
+
+	return policy.Authorize(claimsContext(), userID, nil, r)
+}
+
+func TestRequireRole(t *testing.T) {
+	require.NoError(t, authorizeLocally(t, RequireRole("admin"), nil))
+	require.NoError(t, authorizeLocally(t, RequireRole("owner", "user"), nil))
+
+	err := authorizeLocally(t, RequireRole("owner", "superuser"), nil)
+	require.Equal(t, custom_problems.Unauthorized(userID, custom_problems.PolicyViolation{Action: "REQUIRE_ROLE::owner|superuser"}), err)
+}
+
+func TestRequireClaimEquals(t *testing.T) {
+	require.NoError(t, authorizeLocally(t, RequireClaimEquals("enlightCompanyId", companyID), nil))
+	require.NoError(t, authorizeLocally(t, RequireClaimEquals("aud", "portal"), nil))
+
+	require.Error(t, authorizeLocally(t, RequireClaimEquals("enlightCompanyId", "other"), nil))
+	require.Error(t, authorizeLocally(t, RequireClaimEquals("aud", "other"), nil))
+	require.Error(t, authorizeLocally(t, RequireClaimEquals("missing", ""), nil))
+}
+
+func TestRequireSameCompany(t *testing.T) {
+	policy := RequireSameCompany(FromPathVar("companyId", "company"))
+
+	request := func(company string) *http.Request {
+		return mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"companyId": company})
+	}
+
+	require.NoError(t, authorizeLocally(t, policy, request(companyID)))
+
+	other := "7c0d3b1e-90a1-4e1f-8b8f-2a4c1d9e6b00"
+	err := authorizeLocally(t, policy, request(other))
+	require.Equal(t, []custom_problems.PolicyViolation{{Action: "REQUIRE_SAME_COMPANY", Resource: other, ResourceType: "company"}}, violations(t, err))
+
+	requireProblem(t, authorizeLocally(t, policy, request("not-a-uuid")), "/problems/malformed-resource-identifier")
+}
+
+func TestRequireTokenUse(t *testing.T) {
+	require.NoError(t, authorizeLocally(t, RequireTokenUse(authentication.TokenUseAccess), nil))
+	require.Error(t, authorizeLocally(t, RequireTokenUse(authentication.TokenUseID), nil))
+}
+
+func TestLocalPolicy_WithoutAuthentication(t *testing.T) {
+	err := RequireRole("admin").Authorize(context.Background(), userID, nil, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.ErrorIs(t, err, ErrNoAuthenticationMiddleware)
+}
+
+func TestLocalPolicy_ComposesWithActionResourcePolicy(t *testing.T) {
+	authorizerMock := authorize_mock.Create()
+	authorizerMock.On("IsAuthorizedWithReason", mock.Anything, userID, policy.Action, resource).Return(false, "", nil)
+
+	err := MultiPolicy{RequireRole("owner"), policy}.Authorize(claimsContext(), userID, authorizerMock, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, []custom_problems.PolicyViolation{
+		{Action: "REQUIRE_ROLE::owner"},
+		{Action: policy.Action, Resource: resource.Id, ResourceType: resource.Type},
+	}, violations(t, err))
+}
+
+func TestLocalPolicy_WithoutAuthorizerClient(t *testing.T) {
+	m := New()
+	m.SetPolicyMatching(route.PathPrefix("/"), MultiPolicy{RequireRole("admin"), policy})
+
+	w := httptest.NewRecorder()
+	m.Middleware()(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(claimsContext()))
+	require.Equal(t, http.StatusNotFound, w.Code, "the remote policy is allowed")
+
+	m = New()
+	m.SetPolicyMatching(route.PathPrefix("/"), AllOf{
+		RequireRole("admin"),
+		ActionResourcePolicy{Action: "CHILD", ResourceExtractor: FromJSONBody("/child", "node")},
+	})
+
+	w = httptest.NewRecorder()
+	m.Middleware()(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"child":`)).WithContext(claimsContext()))
+	require.Equal(t, http.StatusNotFound, w.Code, "the resource of the remote policy is not extracted")
+
+	m = New()
+	m.SetPolicyMatching(route.PathPrefix("/"), AnyOf{RequireRole("owner"), policy})
+
+	w = httptest.NewRecorder()
+	m.Middleware()(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(claimsContext()))
+	require.Equal(t, http.StatusNotFound, w.Code, "the remote alternative is allowed")
+
+	m = New()
+	m.SetPolicyMatching(route.PathPrefix("/"), RequireRole("owner"))
+
+	w = httptest.NewRecorder()
+	m.Middleware()(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(claimsContext()))
+	require.Equal(t, http.StatusForbidden, w.Code, "the local policy is enforced")
+}
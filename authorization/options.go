@@ -7,3 +7,11 @@ func WithAuthorizerClient(client AuthorizerClient) Option {
 		m.authorizerClient = client
 	}
 }
+
+// WithBulkConcurrency limits the number of concurrent calls made by the
+// ResourceFilter when the AuthorizerClient does not support bulk calls.
+func WithBulkConcurrency(concurrency int) Option {
+	return func(m *Middleware) {
+		m.bulkConcurrency = concurrency
+	}
+}
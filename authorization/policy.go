@@ -14,10 +14,16 @@ import (
 	custom_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
 )
 
+// Policy authorizes a request. Policies combined by MultiPolicy, AllOf and
+// AnyOf are evaluated concurrently, see ResourceExtractor.
 type Policy interface {
 	Authorize(ctx context.Context, userID string, authorizer AuthorizerClient, r *http.Request) error
 }
 
+// ResourceExtractor returns the resource of the request to authorize. The
+// policies of a MultiPolicy, AllOf or AnyOf are evaluated concurrently on the
+// same request, so extractors must neither modify the request nor read its
+// body, other than through FromJSONBody which shares a single read.
 type ResourceExtractor func(ctx context.Context, r *http.Request) (*proto.Origin, error)
 
 type ActionResourcePolicy struct {
@@ -36,16 +42,8 @@ func (p ActionResourcePolicy) Authorize(ctx context.Context, userID string, auth
 	}
 
 	ok, reason, err := authorizer.IsAuthorizedWithReason(ctx, userID, p.Action, resource)
-
-	if code := status.Code(err); code != codes.OK {
-		switch code {
-		case codes.Canceled:
-			return context.Canceled
-		case codes.DeadlineExceeded:
-			return context.DeadlineExceeded
-		default:
-			return fmt.Errorf("unable to call IsAuthorizedWithReasonWithContext: %w", err)
-		}
+	if err != nil {
+		return authorizerError("IsAuthorizedWithReasonWithContext", err)
 	}
 
 	if !ok {
@@ -69,23 +67,126 @@ func (p ActionResourcePolicy) Authorize(ctx context.Context, userID string, auth
 	return nil
 }
 
+// MultiPolicy requires all of its policies to authorize the request. The
+// policies are evaluated concurrently, and the violations of all unauthorized
+// policies are aggregated into a single UnauthorizedProblem. Any other error,
+// e.g. ResourceNotFoundProblem, cancels the remaining policies and is returned.
 type MultiPolicy []Policy
 
 func (policies MultiPolicy) Authorize(ctx context.Context, userID string, authorizer AuthorizerClient, r *http.Request) error {
+	errs, stoppedBy := evaluate(ctx, policies, userID, authorizer, r, func(err error) bool {
+		return err != nil && !isUnauthorized(err)
+	})
+
+	if stoppedBy >= 0 {
+		return errs[stoppedBy]
+	}
+
+	return aggregate(userID, errs)
+}
+
+// AllOf requires all of its policies to authorize the request. The policies are
+// evaluated concurrently, and the first error cancels the remaining policies
+// and is returned.
+type AllOf []Policy
+
+func (policies AllOf) Authorize(ctx context.Context, userID string, authorizer AuthorizerClient, r *http.Request) error {
+	errs, stoppedBy := evaluate(ctx, policies, userID, authorizer, r, func(err error) bool {
+		return err != nil
+	})
+
+	if stoppedBy >= 0 {
+		return errs[stoppedBy]
+	}
+
+	return nil
+}
+
+// AnyOf requires at least one of its policies to authorize the request. The
+// policies are evaluated concurrently, and the first policy authorizing the
+// request cancels the remaining policies. If no policy authorizes the request,
+// the violations of the unauthorized policies are aggregated into a single
+// UnauthorizedProblem, unless any other error occurred which is returned.
+type AnyOf []Policy
+
+func (policies AnyOf) Authorize(ctx context.Context, userID string, authorizer AuthorizerClient, r *http.Request) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	errs, stoppedBy := evaluate(ctx, policies, userID, authorizer, r, func(err error) bool {
+		return err == nil
+	})
+
+	if stoppedBy >= 0 {
+		return nil
+	}
+
+	for _, err := range errs {
+		if !isUnauthorized(err) {
+			return err
+		}
+	}
+
+	return aggregate(userID, errs)
+}
+
+// evaluate authorizes the request with all policies concurrently, until stop
+// returns true for the result of a policy. The results are returned in the order
+// of the policies, together with the index of the policy which stopped the
+// evaluation, or -1. Results of the other policies are undefined once stopped.
+// All policies share r, which must therefore be treated as read-only.
+func evaluate(ctx context.Context, policies []Policy, userID string, authorizer AuthorizerClient, r *http.Request, stop func(error) bool) ([]error, int) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		index int
+		err   error
+	}
+
+	errs := make([]error, len(policies))
+	results := make(chan result, len(policies))
+
+	for i, policy := range policies {
+		go func() {
+			results <- result{index: i, err: policy.Authorize(ctx, userID, authorizer, r)}
+		}()
+	}
+
+	stoppedBy := -1
+
+	for range policies {
+		res := <-results
+		errs[res.index] = res.err
+
+		if stoppedBy < 0 && stop(res.err) {
+			stoppedBy = res.index
+
+			cancel()
+		}
+	}
+
+	return errs, stoppedBy
+}
+
+func isUnauthorized(err error) bool {
+	var problem custom_problems.UnauthorizedProblem
+	return errors.As(err, &problem)
+}
+
+// aggregate returns an UnauthorizedProblem with the violations of all errs, or
+// nil if none of them are UnauthorizedProblems.
+func aggregate(userID string, errs []error) error {
 	anyErr := false
 	multiErr := custom_problems.Unauthorized(userID)
 
-	for _, policy := range policies {
-		if err := policy.Authorize(ctx, userID, authorizer, r); err != nil {
+	for _, err := range errs {
+		var problem custom_problems.UnauthorizedProblem
+		if errors.As(err, &problem) {
 			anyErr = true
 
-			var problem custom_problems.UnauthorizedProblem
-			if errors.As(err, &problem) {
-				multiErr.Violations = append(multiErr.Violations, problem.Violations...)
-				continue
-			}
-
-			return err
+			multiErr.Violations = append(multiErr.Violations, problem.Violations...)
 		}
 	}
 
@@ -95,3 +196,18 @@ func (policies MultiPolicy) Authorize(ctx context.Context, userID string, author
 
 	return nil
 }
+
+// authorizerError maps the gRPC status of errors returned by the authorizer to
+// the context errors they represent.
+func authorizerError(method string, err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return fmt.Errorf("unable to call %s: %w", method, err)
+	}
+}
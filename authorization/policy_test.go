@@ -2,9 +2,12 @@ package authorization
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	authorize "github.com/SKF/go-enlight-authorizer/client"
@@ -12,6 +15,8 @@ import (
 	proto "github.com/SKF/proto/v2/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	custom_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
 )
 
 func TestActionResourcePolicyWithOnlyAction(t *testing.T) {
@@ -49,3 +54,130 @@ func TestActionResourcePolicyWithOnlyAction_Unauthorized(t *testing.T) {
 
 	authorizerMock.AssertExpectations(t)
 }
+
+type policyFunc func(ctx context.Context) error
+
+func (f policyFunc) Authorize(ctx context.Context, userID string, authorizer AuthorizerClient, r *http.Request) error {
+	return f(ctx)
+}
+
+func allow() Policy {
+	return policyFunc(func(context.Context) error { return nil })
+}
+
+func deny(action string) Policy {
+	return policyFunc(func(context.Context) error {
+		return custom_problems.Unauthorized(userID, custom_problems.PolicyViolation{Action: action})
+	})
+}
+
+func fail(err error) Policy {
+	return policyFunc(func(context.Context) error { return err })
+}
+
+// blocking waits until the evaluation is canceled, and records that it was.
+func blocking(canceled *atomic.Bool) Policy {
+	return policyFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		canceled.Store(true)
+
+		return ctx.Err()
+	})
+}
+
+func violations(t *testing.T, err error) []custom_problems.PolicyViolation {
+	t.Helper()
+
+	var problem custom_problems.UnauthorizedProblem
+	require.ErrorAs(t, err, &problem)
+
+	return problem.Violations
+}
+
+func authorizePolicy(policy Policy) error {
+	return policy.Authorize(context.Background(), userID, nil, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestMultiPolicy_AggregatesViolations(t *testing.T) {
+	err := authorizePolicy(MultiPolicy{deny("A"), allow(), deny("B")})
+
+	require.Equal(t, []custom_problems.PolicyViolation{{Action: "A"}, {Action: "B"}}, violations(t, err))
+	require.NoError(t, authorizePolicy(MultiPolicy{allow(), allow()}))
+	require.NoError(t, authorizePolicy(MultiPolicy{}))
+}
+
+func TestMultiPolicy_EvaluatesConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+
+	started.Add(2)
+
+	wait := policyFunc(func(context.Context) error {
+		started.Done()
+		started.Wait()
+
+		return nil
+	})
+
+	require.NoError(t, authorizePolicy(MultiPolicy{wait, wait}), "both policies run at the same time")
+}
+
+func TestMultiPolicy_OtherErrorCancels(t *testing.T) {
+	var canceled atomic.Bool
+
+	notFound := custom_problems.ResourceNotFound("node-1", "node")
+	err := authorizePolicy(MultiPolicy{deny("A"), blocking(&canceled), fail(notFound)})
+
+	require.Equal(t, notFound, err)
+	require.True(t, canceled.Load())
+}
+
+func TestAllOf_FailFast(t *testing.T) {
+	var canceled atomic.Bool
+
+	err := authorizePolicy(AllOf{blocking(&canceled), deny("A")})
+
+	require.Equal(t, []custom_problems.PolicyViolation{{Action: "A"}}, violations(t, err))
+	require.True(t, canceled.Load())
+	require.NoError(t, authorizePolicy(AllOf{allow(), allow()}))
+}
+
+func TestAnyOf(t *testing.T) {
+	var canceled atomic.Bool
+
+	require.NoError(t, authorizePolicy(AnyOf{blocking(&canceled), deny("A"), allow()}))
+	require.True(t, canceled.Load())
+
+	err := authorizePolicy(AnyOf{deny("A"), deny("B")})
+	require.Equal(t, []custom_problems.PolicyViolation{{Action: "A"}, {Action: "B"}}, violations(t, err))
+
+	internal := errors.New("authorizer unavailable")
+	require.Equal(t, internal, authorizePolicy(AnyOf{deny("A"), fail(internal)}))
+
+	require.NoError(t, authorizePolicy(AnyOf{}))
+}
+
+func TestNestedPolicies(t *testing.T) {
+	policy := AnyOf{
+		AllOf{deny("A"), allow()},
+		MultiPolicy{deny("B"), deny("C")},
+	}
+
+	require.Equal(t, []custom_problems.PolicyViolation{{Action: "A"}, {Action: "B"}, {Action: "C"}}, violations(t, authorizePolicy(policy)))
+
+	policy = AnyOf{
+		AllOf{deny("A"), allow()},
+		MultiPolicy{allow(), allow()},
+	}
+
+	require.NoError(t, authorizePolicy(policy))
+}
+
+func TestParentCancellation(t *testing.T) {
+	var canceled atomic.Bool
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := MultiPolicy{blocking(&canceled)}.Authorize(ctx, userID, nil, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.ErrorIs(t, err, context.Canceled)
+}
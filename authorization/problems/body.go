@@ -0,0 +1,44 @@
+package problems
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+type RequestBodyTooLargeProblem struct {
+	problems.BasicProblem
+	Limit int64 `json:"limit"`
+}
+
+// RequestBodyTooLarge is returned when the body, read to find the resource to
+// authorize, is larger than limit bytes.
+func RequestBodyTooLarge(limit int64) RequestBodyTooLargeProblem {
+	return RequestBodyTooLargeProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/request-body-too-large",
+			Title:  "The request body is too large.",
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("The request body must not be larger than %d bytes.", limit),
+		},
+		Limit: limit,
+	}
+}
+
+type UnreadableRequestBodyProblem struct {
+	problems.BasicProblem
+}
+
+// UnreadableRequestBody is returned when the body, read to find the resource to
+// authorize, could not be read, e.g. because the client disconnected.
+func UnreadableRequestBody() UnreadableRequestBodyProblem {
+	return UnreadableRequestBodyProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/unreadable-request-body",
+			Title:  "The request body could not be read.",
+			Status: http.StatusBadRequest,
+			Detail: "The request body ended unexpectedly.",
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package problems
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+type InvalidResourceIdentifierProblem struct {
+	problems.BasicProblem
+	Parameter    string `json:"parameter,omitempty"`
+	Location     string `json:"location,omitempty"`
+	ResourceType string `json:"resourceType,omitempty"`
+}
+
+// MissingResourceIdentifier is returned when the identifier of the resource to
+// authorize is missing from the request, location is e.g. "path" or "query".
+func MissingResourceIdentifier(location, parameter, resourceType string) InvalidResourceIdentifierProblem {
+	return InvalidResourceIdentifierProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/missing-resource-identifier",
+			Title:  "The identifier of the resource is missing.",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf(`The %s parameter "%s" identifying the %s must be provided.`, location, parameter, resourceType),
+		},
+		Parameter:    parameter,
+		Location:     location,
+		ResourceType: resourceType,
+	}
+}
+
+// MalformedResourceIdentifier is returned when the identifier of the resource to
+// authorize is not valid, e.g. not an UUID.
+func MalformedResourceIdentifier(location, parameter, resourceType string) InvalidResourceIdentifierProblem {
+	return InvalidResourceIdentifierProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/malformed-resource-identifier",
+			Title:  "The identifier of the resource is malformed.",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf(`The %s parameter "%s" identifying the %s is not a valid identifier.`, location, parameter, resourceType),
+		},
+		Parameter:    parameter,
+		Location:     location,
+		ResourceType: resourceType,
+	}
+}
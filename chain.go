@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Stage is the position of a middleware in a Chain, middlewares of lower stages
+// run before, i.e. wrap, middlewares of higher stages.
+type Stage int
+
+// The access log wraps all middlewares but recovery and requestid, to also log
+// the requests rejected or redirected by them and include them in the latency.
+const (
+	StageRecovery        Stage = 100
+	StageRequestID       Stage = 200
+	StageAccessLog       Stage = 300
+	StageHSTS            Stage = 400
+	StageSecurityHeaders Stage = 500
+	StageCORS            Stage = 600
+	StageClientID        Stage = 700
+	StageAuthentication  Stage = 800
+	StageSpanDecorator   Stage = 900
+	StageAuthorization   Stage = 1000
+)
+
+var stageNames = map[Stage]string{
+	StageRecovery:        "recovery",
+	StageRequestID:       "requestid",
+	StageAccessLog:       "accesslog",
+	StageHSTS:            "hsts",
+	StageSecurityHeaders: "securityheaders",
+	StageCORS:            "cors",
+	StageClientID:        "clientid",
+	StageAuthentication:  "authentication",
+	StageSpanDecorator:   "spandecorator",
+	StageAuthorization:   "authorization",
+}
+
+func (s Stage) String() string {
+	if name, found := stageNames[s]; found {
+		return name
+	}
+
+	return fmt.Sprintf("stage(%d)", int(s))
+}
+
+// requirements are the stages which must be part of the chain for a stage to work.
+var requirements = map[Stage][]Stage{
+	StageAuthorization: {StageAuthentication},
+}
+
+// Link is a middleware which knows its stage, implemented by the middlewares
+// of this module.
+type Link interface {
+	Middleware() func(http.Handler) http.Handler
+	Stage() Stage
+}
+
+type link struct {
+	stage      Stage
+	middleware func(http.Handler) http.Handler
+}
+
+func (l link) Middleware() func(http.Handler) http.Handler {
+	return l.middleware
+}
+
+func (l link) Stage() Stage {
+	return l.stage
+}
+
+// Func adds an arbitrary middleware to a Chain at the given stage, e.g.
+// StageAuthentication+1 to run right after authentication.
+func Func(stage Stage, middleware func(http.Handler) http.Handler) Link {
+	return link{stage: stage, middleware: middleware}
+}
+
+var (
+	ErrMissingRequirement = errors.New("middleware chain is missing a required middleware")
+	ErrDuplicateStage     = errors.New("middleware chain contains a middleware twice")
+)
+
+// Chain returns a middleware running the links in the order of their stages,
+// regardless of the order they are given in. Links of the same stage run in the
+// given order. Chain returns an error if a middleware is missing a middleware it
+// depends on, e.g. authorization without authentication, or if a middleware of
+// this module is given twice.
+func Chain(links ...Link) (func(http.Handler) http.Handler, error) {
+	ordered := slices.Clone(links)
+	slices.SortStableFunc(ordered, func(a, b Link) int {
+		return int(a.Stage() - b.Stage())
+	})
+
+	if err := validate(ordered); err != nil {
+		return nil, err
+	}
+
+	middlewares := make([]func(http.Handler) http.Handler, 0, len(ordered))
+	for _, l := range ordered {
+		middlewares = append(middlewares, l.Middleware())
+	}
+
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+
+		return next
+	}, nil
+}
+
+// MustChain is like Chain but panics if the chain is invalid.
+func MustChain(links ...Link) func(http.Handler) http.Handler {
+	chain, err := Chain(links...)
+	if err != nil {
+		panic(err)
+	}
+
+	return chain
+}
+
+func validate(links []Link) error {
+	stages := make(map[Stage]bool, len(links))
+
+	for _, l := range links {
+		_, known := stageNames[l.Stage()]
+		if known && stages[l.Stage()] {
+			return fmt.Errorf("%w: %s", ErrDuplicateStage, l.Stage())
+		}
+
+		stages[l.Stage()] = true
+	}
+
+	for _, l := range links {
+		for _, required := range requirements[l.Stage()] {
+			if !stages[required] {
+				return fmt.Errorf("%w: %s requires %s", ErrMissingRequirement, l.Stage(), required)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/authentication"
+	"github.com/SKF/go-enlight-middleware/authorization"
+	"github.com/SKF/go-enlight-middleware/recovery"
+	"github.com/SKF/go-enlight-middleware/spandecorator"
+)
+
+func record(calls *[]string, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+
+	chain, err := middleware.Chain(
+		middleware.Func(middleware.StageAuthorization, record(&calls, "authorization")),
+		middleware.Func(middleware.StageAuthentication+1, record(&calls, "custom")),
+		middleware.Func(middleware.StageAuthentication, record(&calls, "authentication")),
+		middleware.Func(middleware.StageRecovery, record(&calls, "recovery")),
+	)
+	require.NoError(t, err)
+
+	chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, []string{"recovery", "authentication", "custom", "authorization", "handler"}, calls)
+}
+
+func TestChain_Middlewares(t *testing.T) {
+	authn := authentication.New(authentication.WithKeySetURL(""))
+
+	chain, err := middleware.Chain(
+		authorization.New(),
+		spandecorator.New(),
+		authn,
+		recovery.New(),
+	)
+	require.NoError(t, err)
+
+	defer authn.Stop()
+
+	w := httptest.NewRecorder()
+	chain(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code, "authentication runs before authorization")
+}
+
+func TestChain_MissingRequirement(t *testing.T) {
+	_, err := middleware.Chain(recovery.New(), authorization.New())
+	require.ErrorIs(t, err, middleware.ErrMissingRequirement)
+	require.ErrorContains(t, err, "authorization requires authentication")
+
+	require.Panics(t, func() { middleware.MustChain(authorization.New()) })
+}
+
+func TestChain_DuplicateStage(t *testing.T) {
+	_, err := middleware.Chain(recovery.New(), recovery.New())
+	require.ErrorIs(t, err, middleware.ErrDuplicateStage)
+
+	_, err = middleware.Chain(
+		middleware.Func(middleware.StageRecovery+1, record(new([]string), "a")),
+		middleware.Func(middleware.StageRecovery+1, record(new([]string), "b")),
+	)
+	require.NoError(t, err, "custom stages may be used several times")
+}
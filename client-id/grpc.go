@@ -0,0 +1,49 @@
+package clientid
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/grpcstatus"
+	"github.com/SKF/go-enlight-middleware/internal/grpcutil"
+)
+
+// UnaryServerInterceptor embeds the client id of gRPC calls, extracted from the
+// metadata by the configured extractor, e.g. "x-client-id" by default.
+func (m *Middleware) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := m.intercept(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor embeds the client id of gRPC streams, see UnaryServerInterceptor.
+func (m *Middleware) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := m.intercept(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, grpcutil.WithContext(ss, ctx))
+	}
+}
+
+func (m *Middleware) intercept(ctx context.Context, fullMethod string) (context.Context, error) {
+	spanCtx, span := m.Tracer.StartSpan(ctx, "ClientID")
+	defer span.End()
+
+	r, err := m.identify(spanCtx, grpcutil.Request(ctx, fullMethod))
+	if err != nil {
+		middleware.RecordProblem(span, err)
+		return nil, grpcstatus.Error(err)
+	}
+
+	return r.Context(), nil
+}
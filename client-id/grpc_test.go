@@ -0,0 +1,98 @@
+package clientid_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	client_id "github.com/SKF/go-enlight-middleware/client-id"
+	"github.com/SKF/go-enlight-middleware/client-id/store"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	mw := client_id.New(
+		client_id.WithRequired(),
+		client_id.WithStore(store.NewLocal().Add(ClientA).Add(ClientD)),
+	).IgnoreMatching(route.Path("/grpc.health.v1.Health/Check"))
+
+	interceptor := mw.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		cid, found := client_id.FromContext(ctx)
+		require.True(t, found)
+
+		return cid.Identifier, nil
+	}
+
+	testCases := []struct {
+		desc     string
+		method   string
+		clientID string
+		expected codes.Code
+	}{
+		{desc: "valid client id", method: "/nodes.Nodes/GetNode", clientID: ClientA.Identifier.String(), expected: codes.OK},
+		{desc: "missing client id", method: "/nodes.Nodes/GetNode", expected: codes.Unauthenticated},
+		{desc: "expired client id", method: "/nodes.Nodes/GetNode", clientID: ClientD.Identifier.String(), expected: codes.PermissionDenied},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if tC.clientID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-client-id", tC.clientID))
+			}
+
+			response, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tC.method}, handler)
+			require.Equal(t, tC.expected, status.Code(err), err)
+
+			if tC.expected == codes.OK {
+				require.Equal(t, ClientA.Identifier, response)
+			}
+		})
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+		func(ctx context.Context, req any) (any, error) { return nil, nil })
+	require.NoError(t, err, "ignored method")
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	mw := client_id.New(
+		client_id.WithRequired(),
+		client_id.WithStore(store.NewLocal().Add(ClientA)),
+	)
+
+	interceptor := mw.StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/nodes.Nodes/WatchNodes"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client-id", ClientA.Identifier.String()))
+
+	err := interceptor(nil, serverStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		cid, found := client_id.FromContext(stream.Context())
+		require.True(t, found)
+		require.Equal(t, ClientA.Identifier, cid.Identifier)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = interceptor(nil, serverStream{ctx: context.Background()}, info, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
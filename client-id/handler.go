@@ -2,6 +2,7 @@ package clientid
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -15,10 +16,16 @@ import (
 	"github.com/SKF/go-enlight-middleware/client-id/models"
 	custom_problems "github.com/SKF/go-enlight-middleware/client-id/problems"
 	"github.com/SKF/go-enlight-middleware/client-id/store"
+	"github.com/SKF/go-enlight-middleware/internal/identity"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
+const metricsName = "clientid"
+
 type Middleware struct {
-	Tracer middleware.Tracer
+	Tracer  middleware.Tracer
+	Metrics middleware.Metrics
 
 	allowedStages models.EnvironmentMask
 
@@ -26,7 +33,7 @@ type Middleware struct {
 	enforcement enforcement.Policy
 	store       store.Store
 
-	notMandatoryClientIDRoutes map[*mux.Route]bool
+	notMandatoryClientIDRoutes route.Any
 }
 
 type (
@@ -41,7 +48,8 @@ var FromContext = models.FromContext
 // from the request header "X-Client-ID", is optional and, using an empty in-memory store.
 func New(opts ...Option) *Middleware {
 	m := &Middleware{
-		Tracer: middleware.DefaultTracer,
+		Tracer:  middleware.DefaultTracer,
+		Metrics: middleware.DefaultMetrics,
 
 		allowedStages: models.Environments{stages.StageProd}.Mask(),
 
@@ -49,7 +57,7 @@ func New(opts ...Option) *Middleware {
 		enforcement: enforcement.Default,
 		store:       new(store.Default),
 
-		notMandatoryClientIDRoutes: map[*mux.Route]bool{},
+		notMandatoryClientIDRoutes: route.Any{},
 	}
 
 	for _, opt := range opts {
@@ -59,57 +67,109 @@ func New(opts ...Option) *Middleware {
 	return m
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageClientID
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, span := m.Tracer.StartSpan(r.Context(), "ClientID")
-			if m.isNotMandatoryClientID(ctx, r) {
+
+			identified, err := m.identify(ctx, r)
+			if err != nil {
+				middleware.RecordProblem(span, err)
+				problems.WriteResponse(ctx, err, w, r)
 				span.End()
-				next.ServeHTTP(w, r)
 
 				return
 			}
 
-			identifier, err := m.extractor.ExtractClientID(r)
-			if enforcement := m.enforcement.OnExtraction(ctx, err); enforcement != nil {
-				problems.WriteResponse(ctx, enforcement, w, r)
-				span.End()
+			span.End()
+			next.ServeHTTP(w, identified)
+		})
+	}
+}
 
-				return
-			}
+// identify returns the request with the client id embedded into its context,
+// or the problem decided by the enforcement policy.
+func (m *Middleware) identify(ctx context.Context, r *http.Request) (*http.Request, error) {
+	if m.isNotMandatoryClientID(ctx, r) {
+		metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeSkipped)
+		return r, nil
+	}
 
-			cid, err := m.store.GetClientID(ctx, identifier)
-			if enforcement := m.enforcement.OnRetrieval(ctx, err); enforcement != nil {
-				problems.WriteResponse(ctx, enforcement, w, r)
-				span.End()
+	result, err := m.identifyClientID(ctx, r)
+	if err != nil {
+		metrics.CountRequest(m.Metrics, metricsName, r, err)
+		return nil, err
+	}
 
-				return
-			}
+	metrics.CountTolerated(m.Metrics, metricsName, r, result.tolerated)
 
-			if !cid.IsEmpty() {
-				err = m.validateClientID(cid)
-				if enforcement := m.enforcement.OnValidation(ctx, err); enforcement != nil {
-					problems.WriteResponse(ctx, enforcement, w, r)
-					span.End()
+	return result.request, nil
+}
 
-					return
-				}
-			}
+// identification is the outcome of identifying the client id of a request,
+// tolerated is the first problem let through by the enforcement policy, e.g. a
+// missing client id, to make it visible in the metrics.
+type identification struct {
+	request   *http.Request
+	tolerated error
+}
 
-			if err == nil {
-				r = r.WithContext(
-					cid.EmbedIntoContext(r.Context()),
-				)
-			}
+func (m *Middleware) identifyClientID(ctx context.Context, r *http.Request) (identification, error) {
+	identifier, err := m.extractor.ExtractClientID(r)
+	if enforcement := m.enforcement.OnExtraction(ctx, err); enforcement != nil {
+		return identification{}, enforcement
+	}
 
-			span.End()
-			next.ServeHTTP(w, r)
-		})
+	tolerated := err
+
+	start := time.Now()
+	cid, err := m.store.GetClientID(ctx, identifier)
+	metrics.ObserveSince(m.Metrics, metricsName, middleware.OperationStoreLookup, r, start)
+
+	if enforcement := m.enforcement.OnRetrieval(ctx, err); enforcement != nil {
+		return identification{}, enforcement
+	}
+
+	if !cid.IsEmpty() {
+		err = m.validateClientID(cid)
+		if enforcement := m.enforcement.OnValidation(ctx, err); enforcement != nil {
+			return identification{}, enforcement
+		}
+	}
+
+	if err == nil {
+		if !cid.IsEmpty() {
+			identity.FromContext(r.Context()).SetClient(cid.Identifier.String(), cid.Name)
+		}
+
+		r = r.WithContext(
+			cid.EmbedIntoContext(r.Context()),
+		)
 	}
+
+	if tolerated == nil {
+		tolerated = err
+	}
+
+	if errors.Is(tolerated, store.ErrNotFound) {
+		tolerated = custom_problems.UnknownClientID()
+	}
+
+	return identification{request: r, tolerated: tolerated}, nil
+}
+
+func (m *Middleware) IgnoreRoute(r *mux.Route) *Middleware {
+	return m.IgnoreMatching(route.Mux(r))
 }
 
-func (m *Middleware) IgnoreRoute(route *mux.Route) *Middleware {
-	m.notMandatoryClientIDRoutes[route] = true
+// IgnoreMatching disables the client id middleware for all requests matched by matcher.
+func (m *Middleware) IgnoreMatching(matcher route.Matcher) *Middleware {
+	m.notMandatoryClientIDRoutes = append(m.notMandatoryClientIDRoutes, matcher)
 	return m
 }
 
@@ -117,7 +177,7 @@ func (m *Middleware) isNotMandatoryClientID(ctx context.Context, r *http.Request
 	_, span := m.Tracer.StartSpan(ctx, "ClientID/isNotMandatoryClientID")
 	defer span.End()
 
-	return m.notMandatoryClientIDRoutes[mux.CurrentRoute(r)]
+	return m.notMandatoryClientIDRoutes.Match(r)
 }
 
 func (m *Middleware) validateClientID(cid ClientID) error {
@@ -1,6 +1,7 @@
 package clientid_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,10 +12,18 @@ import (
 	"github.com/SKF/go-utility/v2/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otel_codes "go.opentelemetry.io/otel/codes"
+	otel_sdk_trace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	middleware "github.com/SKF/go-enlight-middleware"
 
 	client_id "github.com/SKF/go-enlight-middleware/client-id"
 	"github.com/SKF/go-enlight-middleware/client-id/models"
 	"github.com/SKF/go-enlight-middleware/client-id/store"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 var (
@@ -205,3 +214,102 @@ func Test(t *testing.T) {
 		})
 	}
 }
+
+func TestProblemIsRecordedOnSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel_sdk_trace.NewTracerProvider(otel_sdk_trace.WithSpanProcessor(recorder))
+
+	mw := client_id.New(client_id.WithRequired())
+	mw.Tracer = &middleware.OpenTelemetryTracer{TracerProvider: provider}
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+	defer root.End()
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	response := doRequest(request, mw)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var span otel_sdk_trace.ReadOnlySpan
+
+	for _, ended := range recorder.Ended() {
+		if ended.Name() == "Middleware/ClientID" {
+			span = ended
+		}
+	}
+
+	require.NotNil(t, span)
+	require.Equal(t, otel_codes.Error, span.Status().Code)
+	require.Contains(t, span.Attributes(), attribute.String(middleware.ProblemTypeKey, "/problems/missing-client-id"))
+	require.Contains(t, span.Attributes(), attribute.Int(middleware.ProblemStatusKey, http.StatusUnauthorized))
+}
+
+func TestIgnoreMatching(t *testing.T) {
+	mw := client_id.New(client_id.WithRequired()).
+		IgnoreMatching(route.PathPrefix("/health"))
+
+	for path, expected := range map[string]int{
+		"/health/ready": http.StatusOK,
+		"/":             http.StatusUnauthorized,
+	} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+
+		mw.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, request)
+
+		require.Equal(t, expected, w.Code, path)
+	}
+}
+
+func TestIgnoreMatchingOnServeMux(t *testing.T) {
+	mw := client_id.New(client_id.WithRequired()).
+		IgnoreMatching(route.Pattern("GET /health"))
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /health", mw.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	serveMux.Handle("GET /nodes", mw.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	for path, expected := range map[string]int{
+		"/health": http.StatusOK,
+		"/nodes":  http.StatusUnauthorized,
+	} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+
+		serveMux.ServeHTTP(w, request)
+
+		require.Equal(t, expected, w.Code, path)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	recorder := new(metrics.Recorder)
+
+	mw := client_id.New(client_id.WithStore(store.NewLocal().Add(ClientA)))
+	mw.Metrics = recorder
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /nodes", mw.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	missing := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+	serveMux.ServeHTTP(httptest.NewRecorder(), missing)
+
+	identified := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+	identified.Header.Set("X-Client-ID", ClientA.Identifier.String())
+	serveMux.ServeHTTP(httptest.NewRecorder(), identified)
+
+	unknown := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+	unknown.Header.Set("X-Client-ID", uuid.New().String())
+	serveMux.ServeHTTP(httptest.NewRecorder(), unknown)
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "clientid", Route: "GET /nodes", Outcome: middleware.OutcomeAllowed, ProblemType: "/problems/missing-client-id"},
+		{Middleware: "clientid", Route: "GET /nodes", Outcome: middleware.OutcomeAllowed},
+		{Middleware: "clientid", Route: "GET /nodes", Outcome: middleware.OutcomeAllowed, ProblemType: "/problems/unknown-client-id"},
+	}, recorder.Requests())
+
+	require.Len(t, recorder.Durations(), 3, "the store is looked up even without a client id")
+	require.Equal(t, metrics.Duration{Middleware: "clientid", Operation: middleware.OperationStoreLookup, Route: "GET /nodes"}, recorder.Durations()[0])
+}
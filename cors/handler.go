@@ -1,47 +1,179 @@
 package cors
 
 import (
+	"context"
 	"net/http"
+	"slices"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/gorilla/mux"
 
 	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
+const metricsName = "cors"
+
 type Middleware struct {
-	Tracer middleware.Tracer
+	Tracer  middleware.Tracer
+	Metrics middleware.Metrics
+
+	policy   Policy
+	policies *route.Table[Policy]
+	router   *mux.Router
 }
 
-// New returns a new cors middleware which act as the default route for all OPTIONS requests and add
-// Access-Control-Allow-Origin header to all responses.
+// New returns a new cors middleware which answers all CORS preflight requests and add
+// Access-Control-Allow-Origin header to all responses. Without providing any Options all origins,
+// methods and headers are allowed.
 func New(opts ...Option) *Middleware {
 	m := &Middleware{
-		Tracer: middleware.DefaultTracer,
+		Tracer:  middleware.DefaultTracer,
+		Metrics: middleware.DefaultMetrics,
+
+		policy:   AllowAll,
+		policies: new(route.Table[Policy]),
 	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
+	m.policy = m.policy.anchored()
+
 	return m
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageCORS
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			_, span := m.Tracer.StartSpan(r.Context(), "CORS")
+			ctx, span := m.Tracer.StartSpan(r.Context(), "CORS")
+
+			policy := m.findPolicyForRequest(ctx, r)
 
-			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if isPreflight(r) {
+				err := policy.writePreflight(w.Header(), r, m.routeMethods(r))
+				if err != nil {
+					middleware.RecordProblem(span, err)
+					problems.WriteResponse(ctx, err, w, r)
+				}
 
-			if r.Method == http.MethodOptions {
-				w.Header().Set("Access-Control-Allow-Methods", "*")
-				w.Header().Set("Access-Control-Allow-Headers", "*")
+				metrics.CountRequest(m.Metrics, metricsName, m.routable(r), err)
 
 				span.End()
 
 				return
 			}
 
+			policy.writeActual(w.Header(), r.Header.Get("Origin"))
+			metrics.CountOutcome(m.Metrics, metricsName, m.routable(r), middleware.OutcomeAllowed)
+
 			span.End()
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// SetPolicy uses the policy, instead of the default policy configured through
+// Options, for requests to the route.
+func (m *Middleware) SetPolicy(r *mux.Route, policy Policy) *Middleware {
+	return m.SetPolicyMatching(route.Mux(r), policy)
+}
+
+// SetPolicyMatching uses the policy for all requests matched by matcher. If
+// several matchers match a request, the policy set first is used.
+func (m *Middleware) SetPolicyMatching(matcher route.Matcher, policy Policy) *Middleware {
+	m.policies.Set(matcher, policy.anchored())
+
+	return m
+}
+
+// findPolicyForRequest returns the policy of the route, preflights are matched
+// using the method of the actual request.
+func (m *Middleware) findPolicyForRequest(ctx context.Context, r *http.Request) Policy {
+	_, span := m.Tracer.StartSpan(ctx, "CORS/findPolicyForRequest")
+	defer span.End()
+
+	if policy, found := m.policies.Lookup(m.routable(r)); found {
+		return policy
+	}
+
+	return m.policy
+}
+
+// routable returns the request as matched against the routes, i.e. preflights
+// use the method of the actual request, and the router of WithRouter is used.
+func (m *Middleware) routable(r *http.Request) *http.Request {
+	if isPreflight(r) {
+		r = r.Clone(r.Context())
+		r.Method = r.Header.Get("Access-Control-Request-Method")
+	}
+
+	if m.router != nil {
+		r = r.WithContext(route.NewContext(r.Context(), route.Gorilla(m.router)))
+	}
+
+	return r
+}
+
+// routeMethods returns the methods of the gorilla/mux routes matching the path
+// of the preflight, or nil if they are unknown or any method is accepted. No
+// methods, but not nil, are returned if no route matches the path.
+func (m *Middleware) routeMethods(r *http.Request) []string {
+	router := m.router
+	if router == nil {
+		if gorilla, ok := route.FromContext(r.Context()).(route.GorillaRouter); ok {
+			router = gorilla.Router
+		}
+	}
+
+	if router == nil {
+		return nil
+	}
+
+	var (
+		methods   = []string{}
+		anyMethod bool
+	)
+
+	router.Walk(func(candidate *mux.Route, _ *mux.Router, _ []*mux.Route) error { //nolint:errcheck
+		if candidate.GetHandler() == nil {
+			return nil
+		}
+
+		candidateMethods, err := candidate.GetMethods()
+		if err != nil {
+			anyMethod = anyMethod || matches(candidate, r, r.Header.Get("Access-Control-Request-Method"))
+			return nil
+		}
+
+		for _, method := range candidateMethods {
+			if !slices.Contains(methods, method) && matches(candidate, r, method) {
+				methods = append(methods, method)
+			}
+		}
+
+		return nil
+	})
+
+	if anyMethod {
+		return nil
+	}
+
+	return methods
+}
+
+func matches(candidate *mux.Route, r *http.Request, method string) bool {
+	clone := r.Clone(r.Context())
+	clone.Method = method
+
+	var match mux.RouteMatch
+
+	return candidate.Match(clone, &match) && match.MatchErr == nil
+}
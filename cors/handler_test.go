@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/SKF/go-rest-utility/problems"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
 
 	"github.com/SKF/go-enlight-middleware/cors"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 func Test_AccessControlHeaders_MethodGet(t *testing.T) {
@@ -34,6 +39,9 @@ func Test_AccessControlHeaders_MethodOptions(t *testing.T) {
 	middleware := cors.New()
 
 	request := httptest.NewRequest(http.MethodOptions, "/", nil)
+	request.Header.Set("Origin", "https://portal.example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	request.Header.Set("Access-Control-Request-Headers", "Authorization")
 
 	response := doRequest(request, middleware)
 	defer response.Body.Close()
@@ -73,3 +81,322 @@ func doRequest(request *http.Request, mw *cors.Middleware) *http.Response {
 
 	return w.Result()
 }
+
+func Test_AllowedOrigins(t *testing.T) {
+	middleware := cors.New(
+		cors.WithAllowedOrigins("https://portal.example.com", "https://*.apps.example.com"),
+		cors.WithAllowedOriginPatterns(
+			regexp.MustCompile(`https://pr-[0-9]+\.preview\.example\.com`),
+			regexp.MustCompile(`https://a\.com|https://a\.com:8443`),
+		),
+	)
+
+	testCases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://portal.example.com", allowed: true},
+		{origin: "https://PORTAL.example.com", allowed: true},
+		{origin: "http://portal.example.com", allowed: false},
+		{origin: "https://a.apps.example.com", allowed: true},
+		{origin: "https://a.b.apps.example.com", allowed: true},
+		{origin: "https://apps.example.com", allowed: false},
+		{origin: "https://evil.com/.apps.example.com", allowed: false},
+		{origin: "https://pr-12.preview.example.com", allowed: true},
+		{origin: "https://pr-12.preview.example.com.evil.com", allowed: false},
+		{origin: "https://a.com:8443", allowed: true},
+		{origin: "https://a.com:8443.evil.com", allowed: false},
+		{origin: "", allowed: false},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.origin, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Origin", tC.origin)
+
+			response := doRequest(request, middleware)
+			defer response.Body.Close()
+
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, "Origin", response.Header.Get("Vary"))
+
+			if tC.allowed {
+				require.Equal(t, tC.origin, response.Header.Get("Access-Control-Allow-Origin"))
+			} else {
+				require.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func Test_AllowCredentials(t *testing.T) {
+	middleware := cors.New(
+		cors.WithAllowedOrigins("https://portal.example.com"),
+		cors.WithAllowCredentials(),
+		cors.WithExposedHeaders("X-Request-ID", "Location"),
+		cors.WithMaxAge(10*time.Minute),
+	)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Origin", "https://portal.example.com")
+
+	response := doRequest(request, middleware)
+	defer response.Body.Close()
+
+	require.Equal(t, "https://portal.example.com", response.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "true", response.Header.Get("Access-Control-Allow-Credentials"))
+	require.Equal(t, "X-Request-ID, Location", response.Header.Get("Access-Control-Expose-Headers"))
+	require.Equal(t, "Origin", response.Header.Get("Vary"))
+
+	request = httptest.NewRequest(http.MethodOptions, "/", nil)
+	request.Header.Set("Origin", "https://portal.example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	request.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+
+	response = doRequest(request, middleware)
+	defer response.Body.Close()
+
+	require.Equal(t, "https://portal.example.com", response.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, http.MethodPut, response.Header.Get("Access-Control-Allow-Methods"))
+	require.Equal(t, "authorization,content-type", response.Header.Get("Access-Control-Allow-Headers"))
+	require.Equal(t, "600", response.Header.Get("Access-Control-Max-Age"))
+}
+
+func Test_AllowCredentialsWithoutAllowedOrigins(t *testing.T) {
+	middleware := cors.New(cors.WithAllowCredentials())
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Origin", "https://evil.com")
+
+	response := doRequest(request, middleware)
+	defer response.Body.Close()
+
+	require.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+	require.Empty(t, response.Header.Get("Access-Control-Allow-Credentials"))
+}
+
+func Test_AllowedMethodsAndHeaders(t *testing.T) {
+	middleware := cors.New(
+		cors.WithAllowedMethods(http.MethodGet, http.MethodPost),
+		cors.WithAllowedHeaders("Authorization", "Content-Type"),
+	)
+
+	request := httptest.NewRequest(http.MethodOptions, "/", nil)
+	request.Header.Set("Origin", "https://portal.example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	request.Header.Set("Access-Control-Request-Headers", "authorization")
+
+	response := doRequest(request, middleware)
+	defer response.Body.Close()
+
+	require.Equal(t, "*", response.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, POST", response.Header.Get("Access-Control-Allow-Methods"))
+	require.Equal(t, "Authorization, Content-Type", response.Header.Get("Access-Control-Allow-Headers"))
+	require.Empty(t, response.Header.Get("Vary"))
+}
+
+func Test_PlainOptionsPassesThrough(t *testing.T) {
+	middleware := cors.New()
+
+	request := httptest.NewRequest(http.MethodOptions, "/", nil)
+
+	response := doRequest(request, middleware)
+	defer response.Body.Close()
+
+	var body CorsEcho
+	json.NewDecoder(response.Body).Decode(&body) // nolint
+	require.True(t, body.Found)
+
+	require.Empty(t, response.Header.Get("Access-Control-Allow-Methods"))
+}
+
+func doPreflight(handler http.Handler, path, method, headers string) *http.Response {
+	request := httptest.NewRequest(http.MethodOptions, path, nil)
+	request.Header.Set("Origin", "https://portal.example.com")
+	request.Header.Set("Access-Control-Request-Method", method)
+
+	if headers != "" {
+		request.Header.Set("Access-Control-Request-Headers", headers)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+
+	return w.Result()
+}
+
+func Test_Preflight_RouteMethods(t *testing.T) {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Path("/nodes/{nodeId}").Methods(http.MethodGet, http.MethodPut).Handler(endpoint)
+	router.Path("/nodes/{nodeId}").Methods(http.MethodDelete).Handler(endpoint)
+	router.Path("/assets").Methods(http.MethodGet).Handler(endpoint)
+
+	testCases := []struct {
+		desc    string
+		handler http.Handler
+	}{
+		{desc: "WithRouter", handler: cors.New(cors.WithRouter(router)).Middleware()(router)},
+		{desc: "route.Middleware", handler: route.Middleware(route.Gorilla(router))(cors.New().Middleware()(router))},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			response := doPreflight(tC.handler, "/nodes/123", http.MethodPut, "")
+			defer response.Body.Close()
+
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			require.Equal(t, "GET, PUT, DELETE", response.Header.Get("Access-Control-Allow-Methods"))
+
+			response = doPreflight(tC.handler, "/assets", http.MethodPut, "")
+			defer response.Body.Close()
+
+			require.Equal(t, http.StatusForbidden, response.StatusCode)
+			require.Empty(t, response.Header.Get("Access-Control-Allow-Methods"))
+
+			var problem problems.BasicProblem
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&problem))
+			require.Equal(t, "/problems/cors-preflight-rejected", problem.ProblemType())
+			require.Contains(t, problem.Detail, `"PUT"`)
+
+			response = doPreflight(tC.handler, "/unknown", http.MethodGet, "")
+			defer response.Body.Close()
+
+			require.Equal(t, http.StatusForbidden, response.StatusCode, "no route matches the path")
+			require.Empty(t, response.Header.Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func Test_Preflight_Rejections(t *testing.T) {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	testCases := []struct {
+		desc     string
+		mw       *cors.Middleware
+		method   string
+		headers  string
+		expected int
+	}{
+		{desc: "allowed", mw: cors.New(cors.WithAllowedHeaders("Authorization", "Content-Type")), method: http.MethodGet, headers: "authorization, content-type", expected: http.StatusOK},
+		{desc: "disallowed header", mw: cors.New(cors.WithAllowedHeaders("Authorization")), method: http.MethodGet, headers: "authorization, x-custom", expected: http.StatusForbidden},
+		{desc: "disallowed method", mw: cors.New(cors.WithAllowedMethods(http.MethodGet)), method: http.MethodDelete, expected: http.StatusForbidden},
+		{desc: "disallowed origin", mw: cors.New(cors.WithAllowedOrigins("https://admin.example.com")), method: http.MethodGet, expected: http.StatusForbidden},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			response := doPreflight(tC.mw.Middleware()(endpoint), "/", tC.method, tC.headers)
+			defer response.Body.Close()
+
+			require.Equal(t, tC.expected, response.StatusCode)
+		})
+	}
+}
+
+func Test_Preflight_PrivateNetwork(t *testing.T) {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for mw, expected := range map[*cors.Middleware]int{
+		cors.New(cors.WithAllowPrivateNetwork()): http.StatusOK,
+		cors.New():                               http.StatusForbidden,
+	} {
+		request := httptest.NewRequest(http.MethodOptions, "/", nil)
+		request.Header.Set("Origin", "https://portal.example.com")
+		request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		request.Header.Set("Access-Control-Request-Private-Network", "true")
+
+		w := httptest.NewRecorder()
+		mw.Middleware()(endpoint).ServeHTTP(w, request)
+
+		require.Equal(t, expected, w.Code)
+
+		if expected == http.StatusOK {
+			require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Private-Network"))
+		}
+	}
+}
+
+func Test_PerRoutePolicies(t *testing.T) {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	router := mux.NewRouter()
+	public := router.Path("/public/nodes").Methods(http.MethodGet).Handler(endpoint)
+	router.Path("/admin/users").Methods(http.MethodGet, http.MethodDelete).Handler(endpoint)
+	router.Path("/nodes").Methods(http.MethodGet).Handler(endpoint)
+
+	mw := cors.New(
+		cors.WithRouter(router),
+		cors.WithAllowedOrigins("https://portal.example.com"),
+		cors.WithAllowCredentials(),
+	).
+		SetPolicy(public, cors.AllowAll).
+		SetPolicyMatching(route.PathPrefix("/admin/"), cors.Policy{
+			AllowedOrigins:   []string{"https://admin.example.com"},
+			AllowedMethods:   []string{"*"},
+			AllowCredentials: true,
+		})
+
+	handler := mw.Middleware()(router)
+
+	testCases := []struct {
+		desc     string
+		method   string
+		path     string
+		origin   string
+		expected string
+	}{
+		{desc: "public route allows any origin", method: http.MethodGet, path: "/public/nodes", origin: "https://other.com", expected: "*"},
+		{desc: "public route preflight", method: http.MethodOptions, path: "/public/nodes", origin: "https://other.com", expected: "*"},
+		{desc: "admin route allows admin portal", method: http.MethodGet, path: "/admin/users", origin: "https://admin.example.com", expected: "https://admin.example.com"},
+		{desc: "admin route rejects portal", method: http.MethodGet, path: "/admin/users", origin: "https://portal.example.com", expected: ""},
+		{desc: "admin route preflight", method: http.MethodOptions, path: "/admin/users", origin: "https://admin.example.com", expected: "https://admin.example.com"},
+		{desc: "default policy", method: http.MethodGet, path: "/nodes", origin: "https://portal.example.com", expected: "https://portal.example.com"},
+		{desc: "default policy rejects admin portal", method: http.MethodGet, path: "/nodes", origin: "https://admin.example.com", expected: ""},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			request := httptest.NewRequest(tC.method, tC.path, nil)
+			request.Header.Set("Origin", tC.origin)
+
+			if tC.method == http.MethodOptions {
+				request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+
+			require.Equal(t, tC.expected, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func Test_Metrics(t *testing.T) {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	router := mux.NewRouter()
+	router.Path("/nodes/{nodeId}").Methods(http.MethodGet).Handler(endpoint)
+
+	recorder := new(metrics.Recorder)
+
+	mw := cors.New(cors.WithRouter(router), cors.WithAllowedMethods(http.MethodGet))
+	mw.Metrics = recorder
+
+	handler := mw.Middleware()(router)
+
+	response := doPreflight(handler, "/nodes/123", http.MethodGet, "")
+	defer response.Body.Close()
+
+	response = doPreflight(handler, "/nodes/123", http.MethodDelete, "")
+	defer response.Body.Close()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "cors", Route: "/nodes/{nodeId}", Outcome: metrics.OutcomeAllowed},
+		{Middleware: "cors", Route: metrics.UnknownRoute, Outcome: metrics.OutcomeRejected, ProblemType: "/problems/cors-preflight-rejected"},
+		{Middleware: "cors", Route: "/nodes/{nodeId}", Outcome: metrics.OutcomeAllowed},
+	}, recorder.Requests())
+}
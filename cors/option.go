@@ -1,3 +1,80 @@
 package cors
 
+import (
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
 type Option func(*Middleware)
+
+// WithPolicy replaces the policy, which by default is AllowAll.
+func WithPolicy(p Policy) Option {
+	return func(m *Middleware) {
+		m.policy = p
+	}
+}
+
+// WithAllowedOrigins sets exact origins, origins with a wildcard subdomain, e.g.
+// "https://*.example.com", or "*" for any origin.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(m *Middleware) {
+		m.policy.AllowedOrigins = origins
+	}
+}
+
+// WithAllowedOriginPatterns allows origins fully matching any of the patterns.
+func WithAllowedOriginPatterns(patterns ...*regexp.Regexp) Option {
+	return func(m *Middleware) {
+		m.policy.AllowedOriginPatterns = patterns
+	}
+}
+
+func WithAllowedMethods(methods ...string) Option {
+	return func(m *Middleware) {
+		m.policy.AllowedMethods = methods
+	}
+}
+
+func WithAllowedHeaders(headers ...string) Option {
+	return func(m *Middleware) {
+		m.policy.AllowedHeaders = headers
+	}
+}
+
+func WithExposedHeaders(headers ...string) Option {
+	return func(m *Middleware) {
+		m.policy.ExposedHeaders = headers
+	}
+}
+
+// WithAllowCredentials allows cookies and Authorization headers, the origin is
+// then reflected instead of answering with "*". The origins must be allowed
+// explicitly through WithAllowedOrigins or WithAllowedOriginPatterns.
+func WithAllowCredentials() Option {
+	return func(m *Middleware) {
+		m.policy.AllowCredentials = true
+	}
+}
+
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(m *Middleware) {
+		m.policy.MaxAge = maxAge
+	}
+}
+
+// WithAllowPrivateNetwork answers Private Network Access preflights.
+func WithAllowPrivateNetwork() Option {
+	return func(m *Middleware) {
+		m.policy.AllowPrivateNetwork = true
+	}
+}
+
+// WithRouter limits the methods allowed in preflights to the methods of the
+// matching routes. The router can also be provided through route.Middleware.
+func WithRouter(router *mux.Router) Option {
+	return func(m *Middleware) {
+		m.router = router
+	}
+}
@@ -0,0 +1,267 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	custom_problems "github.com/SKF/go-enlight-middleware/cors/problems"
+)
+
+const wildcard = "*"
+
+// Policy configures which cross-origin requests are allowed and what is exposed
+// to them.
+type Policy struct {
+	// AllowedOrigins contains exact origins, e.g. "https://example.com", origins
+	// with a wildcard subdomain, e.g. "https://*.example.com", or "*" for any
+	// origin. "*" allows no origin at all when AllowCredentials is set.
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns contains regular expressions matching the whole origin.
+	AllowedOriginPatterns []*regexp.Regexp
+
+	// AllowedMethods contains the methods allowed in preflights, or "*". The
+	// methods are further limited to the methods of the matching routes.
+	AllowedMethods []string
+
+	// AllowedHeaders contains the request headers allowed in preflights, or "*".
+	AllowedHeaders []string
+
+	// ExposedHeaders contains the response headers readable by the browser.
+	ExposedHeaders []string
+
+	// AllowCredentials allows cookies and Authorization headers, which requires
+	// the origin to be reflected instead of answering with "*". Only origins
+	// explicitly allowed, i.e. not through "*", are reflected.
+	AllowCredentials bool
+
+	// AllowPrivateNetwork allows requests from public websites to this service
+	// in a private network, see https://wicg.github.io/private-network-access/.
+	AllowPrivateNetwork bool
+
+	// MaxAge is how long the result of a preflight may be cached.
+	MaxAge time.Duration
+}
+
+// AllowAll is the default policy, allowing any origin, method and header without credentials.
+var AllowAll = Policy{
+	AllowedOrigins: []string{wildcard},
+	AllowedMethods: []string{wildcard},
+	AllowedHeaders: []string{wildcard},
+}
+
+// isPreflight returns if the request is a CORS preflight rather than a plain
+// OPTIONS request, which should be handled by the router.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// anyOrigin returns if the response is the same for all origins, i.e. "*".
+func (p Policy) anyOrigin() bool {
+	return !p.AllowCredentials && slices.Contains(p.AllowedOrigins, wildcard)
+}
+
+func (p Policy) isOriginAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		// Reflecting any origin with credentials would let any website make
+		// requests on behalf of the user.
+		if allowed == wildcard && !p.AllowCredentials {
+			return true
+		}
+
+		if strings.EqualFold(allowed, origin) || matchesWildcardSubdomain(allowed, origin) {
+			return true
+		}
+	}
+
+	for _, pattern := range p.AllowedOriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anchored returns the policy with the origin patterns anchored, to only match
+// whole origins.
+func (p Policy) anchored() Policy {
+	patterns := make([]*regexp.Regexp, 0, len(p.AllowedOriginPatterns))
+
+	for _, pattern := range p.AllowedOriginPatterns {
+		patterns = append(patterns, regexp.MustCompile(`^(?:`+pattern.String()+`)$`))
+	}
+
+	p.AllowedOriginPatterns = patterns
+
+	return p
+}
+
+// matchesWildcardSubdomain matches "https://a.example.com" against the allowed
+// origin "https://*.example.com", but not "https://example.com" itself.
+func matchesWildcardSubdomain(allowed, origin string) bool {
+	prefix, suffix, found := strings.Cut(strings.ToLower(allowed), "*")
+	if !found {
+		return false
+	}
+
+	origin = strings.ToLower(origin)
+
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+
+	return !strings.ContainsAny(subdomain, "/:")
+}
+
+// writeOrigin writes the headers common to preflights and actual requests, it
+// returns false if the origin is not allowed.
+func (p Policy) writeOrigin(header http.Header, origin string) bool {
+	if p.anyOrigin() {
+		header.Set("Access-Control-Allow-Origin", wildcard)
+		return true
+	}
+
+	header.Add("Vary", "Origin")
+
+	if !p.isOriginAllowed(origin) {
+		return false
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	return true
+}
+
+func (p Policy) writeActual(header http.Header, origin string) {
+	if !p.writeOrigin(header, origin) {
+		return
+	}
+
+	if len(p.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+	}
+}
+
+// writePreflight answers the preflight, routeMethods are the methods of the
+// routes matching the request, empty if no route matches, or nil if unknown. A problem is returned if the
+// requested origin, method or headers are not allowed.
+func (p Policy) writePreflight(header http.Header, r *http.Request, routeMethods []string) error {
+	origin := r.Header.Get("Origin")
+	if !p.writeOrigin(header, origin) {
+		return custom_problems.PreflightRejected(fmt.Sprintf("The origin %q is not allowed.", origin))
+	}
+
+	method := r.Header.Get("Access-Control-Request-Method")
+
+	methods := p.allowedMethods(routeMethods)
+	if len(methods) == 0 {
+		return custom_problems.PreflightRejected(fmt.Sprintf("The method %q is not allowed, no route matches the path.", method))
+	}
+
+	if !slices.Contains(methods, wildcard) && !slices.Contains(methods, method) {
+		return custom_problems.PreflightRejected(fmt.Sprintf(
+			"The method %q is not allowed, allowed methods are: %s.", method, strings.Join(methods, ", "),
+		))
+	}
+
+	requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if header := p.disallowedHeader(requestedHeaders); header != "" {
+		return custom_problems.PreflightRejected(fmt.Sprintf("The request header %q is not allowed.", header))
+	}
+
+	if r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		if !p.AllowPrivateNetwork {
+			return custom_problems.PreflightRejected("Requests to the private network are not allowed.")
+		}
+
+		header.Set("Access-Control-Allow-Private-Network", "true")
+	}
+
+	header.Set("Access-Control-Allow-Methods", p.allowed(methods, method))
+
+	if allowedHeaders := p.allowed(p.AllowedHeaders, requestedHeaders); allowedHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", allowedHeaders)
+	}
+
+	if p.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+
+	return nil
+}
+
+// allowedMethods limits the allowed methods of the policy to the methods of the routes.
+func (p Policy) allowedMethods(routeMethods []string) []string {
+	if routeMethods == nil {
+		return p.AllowedMethods
+	}
+
+	if slices.Contains(p.AllowedMethods, wildcard) {
+		return routeMethods
+	}
+
+	var methods []string
+
+	for _, method := range routeMethods {
+		if slices.Contains(p.AllowedMethods, method) {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+// disallowedHeader returns the first of the comma separated headers which is not allowed.
+func (p Policy) disallowedHeader(requested string) string {
+	if slices.Contains(p.AllowedHeaders, wildcard) {
+		return ""
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(header))
+		if header == "" {
+			continue
+		}
+
+		if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool {
+			return strings.EqualFold(allowed, header)
+		}) {
+			return header
+		}
+	}
+
+	return ""
+}
+
+// allowed returns the value of an Allow header. A wildcard is only honored by
+// browsers for requests without credentials, otherwise the request is reflected.
+func (p Policy) allowed(values []string, requested string) string {
+	if slices.Contains(values, wildcard) {
+		if p.AllowCredentials {
+			return requested
+		}
+
+		return wildcard
+	}
+
+	return strings.Join(values, ", ")
+}
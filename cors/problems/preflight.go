@@ -0,0 +1,22 @@
+package problems
+
+import (
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+type PreflightRejectedProblem struct {
+	problems.BasicProblem
+}
+
+func PreflightRejected(detail string) PreflightRejectedProblem {
+	return PreflightRejectedProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/cors-preflight-rejected",
+			Title:  "The cross-origin request is not allowed.",
+			Status: http.StatusForbidden,
+			Detail: detail,
+		},
+	}
+}
@@ -0,0 +1,165 @@
+// Package grpcstatus converts problems into gRPC statuses, giving gRPC clients
+// the same information as the RFC 7807 responses of REST clients.
+package grpcstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	authentication_problems "github.com/SKF/go-enlight-middleware/authentication/problems"
+	authorization_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
+)
+
+// basicFields are the members of problems.BasicProblem, which are part of the
+// status itself rather than its metadata.
+var basicFields = map[string]bool{
+	"type":          true,
+	"title":         true,
+	"status":        true,
+	"detail":        true,
+	"instance":      true,
+	"correlationId": true,
+}
+
+// unauthenticatedTypes are the problems of invalid tokens, which are
+// codes.Unauthenticated even if their HTTP status is 400.
+var unauthenticatedTypes = map[string]bool{
+	authentication_problems.NoToken().Type:           true,
+	authentication_problems.MalformedToken().Type:    true,
+	authentication_problems.UnverifiableToken().Type: true,
+	authentication_problems.ExpiredToken().Type:      true,
+	authentication_problems.NotYetValidToken().Type:  true,
+	authentication_problems.InvalidToken("").Type:    true,
+}
+
+// FromError converts an error into a status, with the code matching the HTTP
+// status of the problem, except for token problems which are all
+// codes.Unauthenticated. The details contain an ErrorInfo with the problem type
+// as reason and all additional problem members, e.g. the violations of an
+// UnauthorizedProblem, as metadata. Errors which are not problems are converted
+// into codes.Internal without exposing the cause.
+func FromError(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+
+	problem := problems.FromError(err)
+
+	message := problem.Error()
+	if _, internal := problem.(problems.InternalProblem); internal {
+		message = problem.ProblemTitle()
+	}
+
+	code := Code(problem.ProblemStatus())
+	if unauthenticatedTypes[problem.ProblemType()] {
+		code = codes.Unauthenticated
+	}
+
+	s := status.New(code, message)
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{
+			Reason:   problem.ProblemType(),
+			Metadata: metadata(problem),
+		},
+	}
+
+	var notFound authorization_problems.ResourceNotFoundProblem
+	if errors.As(problem, &notFound) {
+		details = append(details, &errdetails.ResourceInfo{
+			ResourceType: notFound.ResourceType,
+			ResourceName: notFound.Resource,
+			Description:  notFound.Detail,
+		})
+	}
+
+	if withDetails, err := s.WithDetails(details...); err == nil {
+		return withDetails
+	}
+
+	return s
+}
+
+// Error is a shorthand for FromError(err).Err().
+func Error(err error) error {
+	return FromError(err).Err()
+}
+
+// Code returns the gRPC code corresponding to the HTTP status code.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	return codes.Internal
+}
+
+// metadata returns the members of the problem which are not part of the basic
+// problem. Strings are kept as is, other values are JSON encoded.
+func metadata(problem problems.Problem) map[string]string {
+	if _, internal := problem.(problems.InternalProblem); internal {
+		return nil
+	}
+
+	encoded, err := json.Marshal(problem)
+	if err != nil {
+		return nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &members); err != nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+
+	for key, value := range members {
+		if basicFields[key] {
+			continue
+		}
+
+		var str string
+		if err := json.Unmarshal(value, &str); err == nil {
+			result[key] = str
+		} else {
+			result[key] = string(value)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
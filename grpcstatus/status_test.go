@@ -0,0 +1,155 @@
+package grpcstatus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	authentication_problems "github.com/SKF/go-enlight-middleware/authentication/problems"
+	authorization_problems "github.com/SKF/go-enlight-middleware/authorization/problems"
+	client_id_problems "github.com/SKF/go-enlight-middleware/client-id/problems"
+	"github.com/SKF/go-enlight-middleware/grpcstatus"
+)
+
+func errorInfo(t *testing.T, s *status.Status) *errdetails.ErrorInfo {
+	t.Helper()
+
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	require.FailNow(t, "status is missing ErrorInfo")
+
+	return nil
+}
+
+func TestFromError(t *testing.T) {
+	expiredAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		desc     string
+		err      error
+		code     codes.Code
+		reason   string
+		metadata map[string]string
+	}{
+		{
+			desc:   "unauthorized",
+			err:    authorization_problems.Unauthorized("user", authorization_problems.PolicyViolation{Action: "HIERARCHY::GET_NODE", Resource: "node-1", ResourceType: "node"}),
+			code:   codes.PermissionDenied,
+			reason: "/problems/unauthorized-resource",
+			metadata: map[string]string{
+				"userId":     "user",
+				"violations": `[{"action":"HIERARCHY::GET_NODE","resource":"node-1","resourceType":"node"}]`,
+			},
+		},
+		{
+			desc:     "resource not found",
+			err:      authorization_problems.ResourceNotFound("node-1", "node"),
+			code:     codes.NotFound,
+			reason:   "/problems/resource-not-found",
+			metadata: map[string]string{"resource": "node-1", "resourceType": "node"},
+		},
+		{
+			desc:     "expired client id",
+			err:      client_id_problems.ExpiredClientID(expiredAt),
+			code:     codes.PermissionDenied,
+			reason:   "/problems/expired-client-id",
+			metadata: map[string]string{"expiredAt": "2024-01-02T03:04:05Z"},
+		},
+		{
+			desc:   "missing client id",
+			err:    client_id_problems.NoClientID("Should be provided."),
+			code:   codes.Unauthenticated,
+			reason: "/problems/missing-client-id",
+		},
+		{
+			desc:   "missing token",
+			err:    authentication_problems.NoToken(),
+			code:   codes.Unauthenticated,
+			reason: "/problems/missing-authentication-token",
+		},
+		{
+			desc:   "malformed token",
+			err:    authentication_problems.MalformedToken(),
+			code:   codes.Unauthenticated,
+			reason: "/problems/malformed-authentication-token",
+		},
+		{
+			desc:   "unverifiable token",
+			err:    authentication_problems.UnverifiableToken(),
+			code:   codes.Unauthenticated,
+			reason: "/problems/unverifiable-authentication-token",
+		},
+		{
+			desc:   "wrapped problem",
+			err:    fmt.Errorf("wrapped: %w", authentication_problems.ExpiredToken()),
+			code:   codes.Internal,
+			reason: "/problems/internal-server-error",
+		},
+		{
+			desc:   "plain error",
+			err:    errors.New("secret"),
+			code:   codes.Internal,
+			reason: "/problems/internal-server-error",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s := grpcstatus.FromError(tC.err)
+
+			require.Equal(t, tC.code, s.Code())
+			require.NotContains(t, s.Message(), "secret")
+
+			info := errorInfo(t, s)
+			require.Equal(t, tC.reason, info.GetReason())
+
+			if tC.metadata == nil {
+				require.Empty(t, info.GetMetadata())
+			} else {
+				require.Equal(t, tC.metadata, info.GetMetadata())
+			}
+		})
+	}
+}
+
+func TestFromError_ResourceInfo(t *testing.T) {
+	s := grpcstatus.FromError(authorization_problems.ResourceNotFound("node-1", "node"))
+
+	var info *errdetails.ResourceInfo
+
+	for _, detail := range s.Details() {
+		if resourceInfo, ok := detail.(*errdetails.ResourceInfo); ok {
+			info = resourceInfo
+		}
+	}
+
+	require.NotNil(t, info)
+	require.Equal(t, "node", info.GetResourceType())
+	require.Equal(t, "node-1", info.GetResourceName())
+}
+
+func TestFromError_Message(t *testing.T) {
+	problem := authentication_problems.ExpiredToken()
+
+	require.Equal(t, problem.Error(), grpcstatus.FromError(problem).Message())
+	require.Equal(t, "Internal Server Error", grpcstatus.FromError(problems.Internal(errors.New("secret"))).Message())
+}
+
+func TestFromError_Context(t *testing.T) {
+	require.Equal(t, codes.Canceled, grpcstatus.FromError(context.Canceled).Code())
+	require.Equal(t, codes.DeadlineExceeded, grpcstatus.FromError(context.DeadlineExceeded).Code())
+	require.Nil(t, grpcstatus.FromError(nil))
+	require.NoError(t, grpcstatus.Error(nil))
+}
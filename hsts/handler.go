@@ -1,54 +1,93 @@
 package hsts
 
 import (
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/SKF/go-rest-utility/problems"
+
 	middleware "github.com/SKF/go-enlight-middleware"
+	custom_problems "github.com/SKF/go-enlight-middleware/hsts/problems"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 const (
 	Header        string        = "Strict-Transport-Security"
 	DefaultMaxAge time.Duration = 365 * 24 * time.Hour
+
+	metricsName = "hsts"
 )
 
 type Middleware struct {
-	Tracer middleware.Tracer
+	Tracer  middleware.Tracer
+	Metrics middleware.Metrics
 
 	maxAge            time.Duration
 	includeSubDomains bool
 	preload           bool
 
-	cachedPolicy string
+	trustedProxies []netip.Prefix
+
+	redirect           bool
+	redirectStatus     int
+	redirectHosts      []string
+	redirectExemptions route.Any
+
+	policy string
 }
 
 func New(opts ...Option) *Middleware {
 	m := &Middleware{
-		Tracer: middleware.DefaultTracer,
+		Tracer:  middleware.DefaultTracer,
+		Metrics: middleware.DefaultMetrics,
 
-		maxAge: DefaultMaxAge,
+		maxAge:         DefaultMaxAge,
+		redirectStatus: http.StatusPermanentRedirect,
 	}
 
 	for _, opt := range opts {
 		opt(m)
 	}
 
+	m.policy = m.buildPolicy()
+
 	return m
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageHSTS
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			_, span := m.Tracer.StartSpan(r.Context(), "HSTS")
-
-			if m.isHTTPS(r) {
-				if m.cachedPolicy == "" {
-					m.cachedPolicy = m.buildPolicy()
+			ctx, span := m.Tracer.StartSpan(r.Context(), "HSTS")
+
+			switch {
+			case m.isHTTPS(r):
+				w.Header().Add(Header, m.policy)
+				metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeAllowed)
+			case m.redirect && !m.redirectExemptions.Match(r):
+				if err := m.redirectToHTTPS(w, r); err != nil {
+					middleware.RecordProblem(span, err)
+					problems.WriteResponse(ctx, err, w, r)
+					metrics.CountRequest(m.Metrics, metricsName, r, err)
+				} else {
+					metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeRedirected)
 				}
 
-				w.Header().Add(Header, m.cachedPolicy)
+				span.End()
+
+				return
+			default:
+				metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeSkipped)
 			}
 
 			span.End()
@@ -57,16 +96,88 @@ func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	}
 }
 
+// isHTTPS returns if the request was made using HTTPS, either directly or to a
+// proxy forwarding it. Forwarded headers are only trusted from the configured
+// proxies, or from anyone if no proxies are configured.
 func (m *Middleware) isHTTPS(r *http.Request) bool {
 	if r.TLS != nil && r.TLS.HandshakeComplete {
 		return true
 	}
 
-	if r.Header.Get("X-Forwarded-Proto") == "https" {
+	if !m.isTrustedProxy(r) {
+		return false
+	}
+
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		return strings.EqualFold(forwardedProto(forwarded), "https")
+	}
+
+	return strings.EqualFold(lastValue(r.Header.Values("X-Forwarded-Proto")), "https")
+}
+
+func (m *Middleware) isTrustedProxy(r *http.Request) bool {
+	if len(m.trustedProxies) == 0 {
 		return true
 	}
 
-	return false
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	return slices.ContainsFunc(m.trustedProxies, func(proxy netip.Prefix) bool {
+		return proxy.Contains(addr)
+	})
+}
+
+// forwardedProto returns the proto parameter of the last element of the RFC 7239
+// Forwarded header, which is the element added by the proxy closest to us.
+func forwardedProto(values []string) string {
+	element := lastValue(values)
+
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(key, "proto") {
+			return strings.Trim(value, `"`)
+		}
+	}
+
+	return ""
+}
+
+// lastValue returns the last of the comma separated values of the header.
+func lastValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	elements := strings.Split(values[len(values)-1], ",")
+
+	return strings.TrimSpace(elements[len(elements)-1])
+}
+
+func (m *Middleware) redirectToHTTPS(w http.ResponseWriter, r *http.Request) error {
+	host := r.Host
+	if hostname, port, err := net.SplitHostPort(host); err == nil && port == "80" {
+		host = hostname
+	}
+
+	if len(m.redirectHosts) > 0 && !slices.ContainsFunc(m.redirectHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host)
+	}) {
+		return custom_problems.HTTPSRequired(host)
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), m.redirectStatus)
+
+	return nil
 }
 
 func (m *Middleware) buildPolicy() string {
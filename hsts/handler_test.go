@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/SKF/go-enlight-middleware/hsts"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/route"
 )
 
 func testHSTSMiddleware(t *testing.T, middleware *hsts.Middleware, server func(http.Handler) *httptest.Server, requestModifier func(*http.Request)) *http.Response {
@@ -104,3 +109,167 @@ func TestWithPreload(t *testing.T) {
 	require.Equal(t, http.StatusOK, response.StatusCode)
 	require.Equal(t, "max-age=63072000; includeSubDomains; preload", response.Header.Get(hsts.Header))
 }
+
+func serve(middleware *hsts.Middleware, r *http.Request) *httptest.ResponseRecorder {
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "OK")
+	})
+
+	w := httptest.NewRecorder()
+	middleware.Middleware()(endpoint).ServeHTTP(w, r)
+
+	return w
+}
+
+func TestWithTrustedProxies(t *testing.T) {
+	middleware := hsts.New(
+		hsts.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")),
+	)
+
+	testCases := []struct {
+		remoteAddr string
+		expected   bool
+	}{
+		{remoteAddr: "10.1.2.3:1234", expected: true},
+		{remoteAddr: "[::1]:1234", expected: true},
+		{remoteAddr: "[::ffff:10.1.2.3]:1234", expected: true},
+		{remoteAddr: "192.168.1.1:1234", expected: false},
+		{remoteAddr: "invalid", expected: false},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.remoteAddr, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			r.RemoteAddr = tC.remoteAddr
+			r.Header.Set("X-Forwarded-Proto", "https")
+
+			w := serve(middleware, r)
+
+			if tC.expected {
+				require.Equal(t, "max-age=31536000", w.Header().Get(hsts.Header))
+			} else {
+				require.Empty(t, w.Header().Get(hsts.Header))
+			}
+		})
+	}
+}
+
+func TestForwardedHeader(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		forwarded  []string
+		xForwarded string
+		expected   bool
+	}{
+		{desc: "https", forwarded: []string{`for=192.0.2.60;proto=https;by=203.0.113.43`}, expected: true},
+		{desc: "quoted", forwarded: []string{`for="[2001:db8:cafe::17]";proto="https"`}, expected: true},
+		{desc: "http", forwarded: []string{`for=192.0.2.60;proto=http`}, expected: false},
+		{desc: "last element decides", forwarded: []string{`proto=https, proto=http`}, expected: false},
+		{desc: "last header decides", forwarded: []string{`proto=http`, `for=10.0.0.1;proto=https`}, expected: true},
+		{desc: "preferred over X-Forwarded-Proto", forwarded: []string{`proto=http`}, xForwarded: "https", expected: false},
+		{desc: "last X-Forwarded-Proto decides", xForwarded: "http, https", expected: true},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+
+			for _, value := range tC.forwarded {
+				r.Header.Add("Forwarded", value)
+			}
+
+			if tC.xForwarded != "" {
+				r.Header.Set("X-Forwarded-Proto", tC.xForwarded)
+			}
+
+			w := serve(hsts.New(), r)
+
+			require.Equal(t, tC.expected, w.Header().Get(hsts.Header) != "")
+		})
+	}
+}
+
+func TestWithHTTPSRedirect(t *testing.T) {
+	middleware := hsts.New(
+		hsts.WithHTTPSRedirect(http.StatusMovedPermanently),
+		hsts.WithRedirectHosts("api.example.com"),
+		hsts.WithRedirectExemptions(route.PathPrefix("/health")),
+	)
+
+	testCases := []struct {
+		desc     string
+		url      string
+		https    bool
+		status   int
+		location string
+	}{
+		{desc: "redirected", url: "http://api.example.com/nodes?id=1", status: http.StatusMovedPermanently, location: "https://api.example.com/nodes?id=1"},
+		{desc: "default port is dropped", url: "http://api.example.com:80/nodes", status: http.StatusMovedPermanently, location: "https://api.example.com/nodes"},
+		{desc: "unknown host", url: "http://evil.com/nodes", status: http.StatusBadRequest},
+		{desc: "exempt path", url: "http://api.example.com/health", status: http.StatusOK},
+		{desc: "https", url: "http://api.example.com/nodes", https: true, status: http.StatusOK},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tC.url, nil)
+
+			if tC.https {
+				r.Header.Set("X-Forwarded-Proto", "https")
+			}
+
+			w := serve(middleware, r)
+
+			require.Equal(t, tC.status, w.Code)
+			require.Equal(t, tC.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestWithHTTPSRedirect_DefaultStatus(t *testing.T) {
+	w := serve(hsts.New(hsts.WithHTTPSRedirect(0)), httptest.NewRequest(http.MethodPost, "http://api.example.com/nodes", nil))
+
+	require.Equal(t, http.StatusPermanentRedirect, w.Code)
+	require.Equal(t, "https://api.example.com/nodes", w.Header().Get("Location"))
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	middleware := hsts.New(hsts.WithPreload())
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			r.Header.Set("X-Forwarded-Proto", "https")
+
+			assert.Equal(t, "max-age=31536000; includeSubDomains; preload", serve(middleware, r).Header().Get(hsts.Header))
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestMetrics(t *testing.T) {
+	recorder := new(metrics.Recorder)
+
+	middleware := hsts.New(hsts.WithHTTPSRedirect(0), hsts.WithRedirectHosts("api.example.com"))
+	middleware.Metrics = recorder
+
+	https := httptest.NewRequest(http.MethodGet, "http://api.example.com/nodes", nil)
+	https.Header.Set("X-Forwarded-Proto", "https")
+
+	serve(middleware, https)
+	serve(middleware, httptest.NewRequest(http.MethodGet, "http://api.example.com/nodes", nil))
+	serve(middleware, httptest.NewRequest(http.MethodGet, "http://evil.com/nodes", nil))
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "hsts", Route: metrics.UnknownRoute, Outcome: metrics.OutcomeAllowed},
+		{Middleware: "hsts", Route: metrics.UnknownRoute, Outcome: metrics.OutcomeRedirected},
+		{Middleware: "hsts", Route: metrics.UnknownRoute, Outcome: metrics.OutcomeRejected, ProblemType: "/problems/https-required"},
+	}, recorder.Requests())
+}
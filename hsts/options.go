@@ -1,6 +1,11 @@
 package hsts
 
-import "time"
+import (
+	"net/netip"
+	"time"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
 
 const (
 	oneYear  = 365 * 24 * time.Hour
@@ -32,3 +37,39 @@ func WithPreload() Option {
 		m.preload = true
 	}
 }
+
+// WithTrustedProxies only trusts the Forwarded and X-Forwarded-Proto headers of
+// requests from the proxies, by default the headers are trusted from anyone.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(m *Middleware) {
+		m.trustedProxies = append(m.trustedProxies, proxies...)
+	}
+}
+
+// WithHTTPSRedirect redirects plain HTTP requests to HTTPS using the status code,
+// e.g. http.StatusPermanentRedirect which is used if status is 0.
+func WithHTTPSRedirect(status int) Option {
+	return func(m *Middleware) {
+		m.redirect = true
+
+		if status != 0 {
+			m.redirectStatus = status
+		}
+	}
+}
+
+// WithRedirectHosts only redirects requests to the hosts, other plain HTTP
+// requests are rejected. This prevents redirects to arbitrary Host headers.
+func WithRedirectHosts(hosts ...string) Option {
+	return func(m *Middleware) {
+		m.redirectHosts = append(m.redirectHosts, hosts...)
+	}
+}
+
+// WithRedirectExemptions serves plain HTTP requests matched by any of the
+// matchers, e.g. health checks from a load balancer.
+func WithRedirectExemptions(matchers ...route.Matcher) Option {
+	return func(m *Middleware) {
+		m.redirectExemptions = append(m.redirectExemptions, matchers...)
+	}
+}
@@ -0,0 +1,23 @@
+package problems
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+type HTTPSRequiredProblem struct {
+	problems.BasicProblem
+}
+
+func HTTPSRequired(host string) HTTPSRequiredProblem {
+	return HTTPSRequiredProblem{
+		BasicProblem: problems.BasicProblem{
+			Type:   "/problems/https-required",
+			Title:  "The request must be made using HTTPS.",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("Requests to the host %q are not redirected to HTTPS.", host),
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package grpcutil
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Request represents a gRPC call as the HTTP/2 request it is transported as,
+// i.e. a POST to the full method name with the metadata as headers. This allows
+// the interceptors to reuse the extractors, matchers and policies of the HTTP
+// middlewares. The full method name is used as pattern, which makes it the route
+// resolved by route.Resolve.
+func Request(ctx context.Context, fullMethod string) *http.Request {
+	header := make(http.Header)
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+	}
+
+	r := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: fullMethod},
+		Header:     header,
+		RequestURI: fullMethod,
+		Pattern:    fullMethod,
+	}
+
+	return r.WithContext(ctx)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithContext replaces the context of the stream.
+func WithContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return serverStream{ServerStream: ss, ctx: ctx}
+}
@@ -0,0 +1,77 @@
+// Package identity shares the identity of the caller, as resolved by the
+// authentication and client id middlewares, with the middlewares wrapping them,
+// e.g. the access log.
+package identity
+
+import (
+	"context"
+	"sync"
+)
+
+// Holder is filled in by the middlewares resolving the identity of the caller.
+// All methods are safe to call on a nil Holder.
+type Holder struct {
+	mutex      sync.Mutex
+	userID     string
+	clientID   string
+	clientName string
+}
+
+type contextKey struct{}
+
+// NewContext returns a context with an empty Holder, to be filled in by the
+// middlewares it is passed to.
+func NewContext(parent context.Context) (context.Context, *Holder) {
+	holder := new(Holder)
+	return context.WithValue(parent, contextKey{}, holder), holder
+}
+
+// FromContext returns the Holder of the context, or nil if there is none.
+func FromContext(ctx context.Context) *Holder {
+	holder, _ := ctx.Value(contextKey{}).(*Holder)
+	return holder
+}
+
+func (h *Holder) SetUserID(userID string) {
+	if h == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.userID = userID
+}
+
+func (h *Holder) SetClient(clientID, clientName string) {
+	if h == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.clientID, h.clientName = clientID, clientName
+}
+
+func (h *Holder) UserID() string {
+	if h == nil {
+		return ""
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.userID
+}
+
+func (h *Holder) Client() (clientID, clientName string) {
+	if h == nil {
+		return "", ""
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.clientID, h.clientName
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SKF/go-rest-utility/problems"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+// Outcome is how a middleware handled a request.
+type Outcome string
+
+const (
+	// OutcomeAllowed requests are passed on to the next handler.
+	OutcomeAllowed Outcome = "allowed"
+	// OutcomeSkipped requests are passed on without being handled, e.g. ignored routes.
+	OutcomeSkipped Outcome = "skipped"
+	// OutcomeRejected requests are answered with a client error problem.
+	OutcomeRejected Outcome = "rejected"
+	// OutcomeError requests are answered with a server error problem.
+	OutcomeError Outcome = "error"
+	// OutcomeCanceled requests were canceled by the client.
+	OutcomeCanceled Outcome = "canceled"
+	// OutcomeRedirected requests are answered with a redirect.
+	OutcomeRedirected Outcome = "redirected"
+)
+
+const (
+	OperationVerification = "verification"
+	OperationAuthorizer   = "authorizer"
+	OperationStoreLookup  = "store_lookup"
+)
+
+// UnknownRoute is used as route of requests which could not be resolved to a
+// route template, to keep the cardinality of the metrics bounded.
+const UnknownRoute = "unknown"
+
+type Metrics interface {
+	// CountRequest counts a request handled by the middleware, problemType is
+	// empty unless the request was answered with a problem.
+	CountRequest(middleware, route string, outcome Outcome, problemType string)
+	// ObserveDuration records the duration of an operation of the middleware.
+	ObserveDuration(middleware, operation, route string, duration time.Duration)
+}
+
+type NilMetrics struct{}
+
+func (NilMetrics) CountRequest(middleware, route string, outcome Outcome, problemType string) {}
+
+func (NilMetrics) ObserveDuration(middleware, operation, route string, duration time.Duration) {}
+
+// Route returns the template of the route of the request.
+func Route(r *http.Request) string {
+	if template, found := route.Resolve(r); found {
+		return template
+	}
+
+	return UnknownRoute
+}
+
+// CountRequest counts the request with the outcome of err, where nil means
+// that the request was allowed. The helpers accept nil Metrics, to support
+// middlewares created without New.
+func CountRequest(m Metrics, middleware string, r *http.Request, err error) {
+	if m == nil {
+		return
+	}
+
+	outcome, problemType := outcomeOf(err)
+
+	m.CountRequest(middleware, Route(r), outcome, problemType)
+}
+
+// CountOutcome counts the request with an outcome which is not decided by an error.
+func CountOutcome(m Metrics, middleware string, r *http.Request, outcome Outcome) {
+	if m == nil {
+		return
+	}
+
+	m.CountRequest(middleware, Route(r), outcome, "")
+}
+
+// CountTolerated counts an allowed request with the problem type of err, which
+// was tolerated by the middleware, e.g. a missing optional client id. A nil err
+// counts a plainly allowed request.
+func CountTolerated(m Metrics, middleware string, r *http.Request, err error) {
+	if m == nil {
+		return
+	}
+
+	if err == nil {
+		m.CountRequest(middleware, Route(r), OutcomeAllowed, "")
+		return
+	}
+
+	m.CountRequest(middleware, Route(r), OutcomeAllowed, problems.FromError(err).ProblemType())
+}
+
+func outcomeOf(err error) (Outcome, string) {
+	if err == nil {
+		return OutcomeAllowed, ""
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return OutcomeCanceled, ""
+	}
+
+	problem := problems.FromError(err)
+	if problem.ProblemStatus() >= http.StatusInternalServerError {
+		return OutcomeError, problem.ProblemType()
+	}
+
+	return OutcomeRejected, problem.ProblemType()
+}
+
+// ObserveSince records the time passed since start as the duration of the operation.
+func ObserveSince(m Metrics, middleware, operation string, r *http.Request, start time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.ObserveDuration(middleware, operation, Route(r), time.Since(start))
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Request is a request counted by the Recorder.
+type Request struct {
+	Middleware  string
+	Route       string
+	Outcome     Outcome
+	ProblemType string
+}
+
+// Duration is a duration observed by the Recorder.
+type Duration struct {
+	Middleware string
+	Operation  string
+	Route      string
+}
+
+// Recorder keeps all counted requests and observed durations, intended to be
+// used by tests.
+type Recorder struct {
+	mutex     sync.Mutex
+	requests  []Request
+	durations []Duration
+}
+
+func (r *Recorder) CountRequest(middleware, route string, outcome Outcome, problemType string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.requests = append(r.requests, Request{
+		Middleware:  middleware,
+		Route:       route,
+		Outcome:     outcome,
+		ProblemType: problemType,
+	})
+}
+
+func (r *Recorder) ObserveDuration(middleware, operation, route string, _ time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.durations = append(r.durations, Duration{
+		Middleware: middleware,
+		Operation:  operation,
+		Route:      route,
+	})
+}
+
+func (r *Recorder) Requests() []Request {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Request(nil), r.requests...)
+}
+
+func (r *Recorder) Durations() []Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Duration(nil), r.durations...)
+}
@@ -0,0 +1,110 @@
+package response
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Writer wraps a http.ResponseWriter to keep track of what has been written.
+type Writer struct {
+	http.ResponseWriter
+
+	status  int
+	written int64
+}
+
+func NewWriter(w http.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: w}
+}
+
+func (w *Writer) WriteHeader(status int) {
+	// Informational responses, e.g. 103 Early Hints, may precede the final status.
+	if w.status == 0 && (status >= http.StatusOK || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *Writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+
+	return n, err
+}
+
+func (w *Writer) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ReadFrom allows the underlying writer to optimize copying, e.g. using sendfile.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	var (
+		n   int64
+		err error
+	)
+
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(r)
+	} else {
+		// Hides ReadFrom of the Writer from io.Copy, which would otherwise recurse.
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+
+	w.written += n
+
+	return n, err
+}
+
+// Hijack allows handlers to take over the connection, e.g. for WebSockets.
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T does not implement http.Hijacker", http.ErrNotSupported, w.ResponseWriter)
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && w.status == 0 {
+		// Nothing can be written through the Writer once hijacked.
+		w.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status is the status code sent to the client, or 0 if nothing has been sent.
+func (w *Writer) Status() int {
+	return w.status
+}
+
+// HeaderWritten returns if the status and headers have been sent to the client.
+func (w *Writer) HeaderWritten() bool {
+	return w.status != 0
+}
+
+// BytesWritten is the size of the body sent to the client.
+func (w *Writer) BytesWritten() int64 {
+	return w.written
+}
@@ -0,0 +1,91 @@
+package response_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/internal/response"
+)
+
+func TestWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := response.NewWriter(recorder)
+
+	require.False(t, w.HeaderWritten())
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("hello")) //nolint:errcheck
+
+	require.True(t, w.HeaderWritten())
+	require.Equal(t, http.StatusCreated, w.Status())
+	require.Equal(t, int64(5), w.BytesWritten())
+	require.Same(t, recorder, http.ResponseWriter(w.Unwrap()).(*httptest.ResponseRecorder)) //nolint:forcetypeassert
+}
+
+func TestWriter_ImplicitStatus(t *testing.T) {
+	w := response.NewWriter(httptest.NewRecorder())
+	w.Write([]byte("hello")) //nolint:errcheck
+
+	require.Equal(t, http.StatusOK, w.Status())
+
+	flushed := response.NewWriter(httptest.NewRecorder())
+	flushed.Flush()
+
+	require.Equal(t, http.StatusOK, flushed.Status())
+}
+
+func TestWriter_InformationalStatus(t *testing.T) {
+	w := response.NewWriter(httptest.NewRecorder())
+	w.WriteHeader(http.StatusEarlyHints)
+
+	require.False(t, w.HeaderWritten(), "informational responses are not final")
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+
+	conn net.Conn
+}
+
+func (r hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, nil, nil
+}
+
+func TestWriter_Hijack(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	w := response.NewWriter(hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server})
+
+	hijacker, ok := http.ResponseWriter(w).(http.Hijacker)
+	require.True(t, ok)
+
+	conn, _, err := hijacker.Hijack()
+	require.NoError(t, err)
+	require.Same(t, server, conn)
+	require.True(t, w.HeaderWritten())
+
+	_, _, err = response.NewWriter(httptest.NewRecorder()).Hijack()
+	require.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestWriter_ReadFrom(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := response.NewWriter(recorder)
+
+	n, err := io.Copy(w, strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(5), n)
+	require.Equal(t, int64(5), w.BytesWritten())
+	require.Equal(t, http.StatusOK, w.Status())
+	require.Equal(t, "hello", recorder.Body.String())
+}
@@ -7,6 +7,15 @@ type Tracer interface {
 	SpanFromContext(ctx context.Context) Span
 }
 
+// StatusCode is the outcome of the operation a span represents.
+type StatusCode uint8
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
 type Span interface {
 	End()
 	AddStringAttribute(name, value string)
@@ -15,12 +24,32 @@ type Span interface {
 	Internal() any
 }
 
+// ErrorRecorder is optionally implemented by spans, like the spans of the
+// tracers of this package, to record typed attributes and errors. It is used
+// by RecordProblem when implemented.
+type ErrorRecorder interface {
+	AddIntAttribute(name string, value int)
+	AddBoolAttribute(name string, value bool)
+
+	// RecordError attaches err to the span, it does not change the status of the span.
+	RecordError(err error)
+	SetStatus(code StatusCode, description string)
+}
+
 type NilSpan struct{}
 
 func (s *NilSpan) End() {}
 
 func (s *NilSpan) AddStringAttribute(name, value string) {}
 
+func (s *NilSpan) AddIntAttribute(name string, value int) {}
+
+func (s *NilSpan) AddBoolAttribute(name string, value bool) {}
+
+func (s *NilSpan) RecordError(err error) {}
+
+func (s *NilSpan) SetStatus(code StatusCode, description string) {}
+
 func (s *NilSpan) Empty() bool {
 	return true
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 
 	dd_trace "gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	dd_ext "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	dd_tracer "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 type DatadogTracer struct{}
 
+var _ ErrorRecorder = datadogSpan{}
+
 type datadogSpan struct {
 	span dd_trace.Span
 }
@@ -21,6 +24,36 @@ func (s datadogSpan) AddStringAttribute(name, value string) {
 	s.span.SetTag(name, value)
 }
 
+func (s datadogSpan) AddIntAttribute(name string, value int) {
+	s.span.SetTag(name, value)
+}
+
+func (s datadogSpan) AddBoolAttribute(name string, value bool) {
+	s.span.SetTag(name, value)
+}
+
+func (s datadogSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.span.SetTag(dd_ext.Error, err)
+}
+
+func (s datadogSpan) SetStatus(code StatusCode, description string) {
+	switch code {
+	case StatusError:
+		s.span.SetTag(dd_ext.Error, true)
+
+		if description != "" {
+			s.span.SetTag(dd_ext.ErrorMsg, description)
+		}
+	case StatusOK:
+		s.span.SetTag(dd_ext.Error, false)
+	case StatusUnset:
+	}
+}
+
 func (s datadogSpan) Empty() bool {
 	return false
 }
@@ -2,9 +2,11 @@ package trace_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	dd_ext "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	dd_tracer_mock "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	dd_tracer "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
@@ -48,3 +50,23 @@ func TestDatadog_StartSpan(t *testing.T) {
 	require.Equal(t, "web.middleware", mock.OperationName())
 	require.Equal(t, "A", mock.Tag("resource.name"))
 }
+
+func TestDatadog_SpanErrorAndStatus(t *testing.T) {
+	mt := dd_tracer_mock.Start()
+	defer mt.Stop()
+
+	_, ctx := dd_tracer.StartSpanFromContext(context.Background(), "web", dd_tracer.ResourceName("GET /"))
+
+	_, span := new(trace.DatadogTracer).StartSpan(ctx, "A")
+	errorRecorder := span.(trace.ErrorRecorder)
+	errorRecorder.AddIntAttribute("int", 1)
+	errorRecorder.AddBoolAttribute("bool", true)
+	errorRecorder.RecordError(errors.New("boom"))
+	errorRecorder.SetStatus(trace.StatusError, "failed")
+
+	mock := span.Internal().(dd_tracer_mock.Span)
+
+	require.Equal(t, 1, mock.Tag("int"))
+	require.Equal(t, true, mock.Tag("bool"))
+	require.Equal(t, "failed", mock.Tag(dd_ext.ErrorMsg))
+}
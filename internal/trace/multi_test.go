@@ -36,6 +36,22 @@ func (s *SpanMock) AddStringAttribute(name, value string) {
 	s.Called(name, value)
 }
 
+func (s *SpanMock) AddIntAttribute(name string, value int) {
+	s.Called(name, value)
+}
+
+func (s *SpanMock) AddBoolAttribute(name string, value bool) {
+	s.Called(name, value)
+}
+
+func (s *SpanMock) RecordError(err error) {
+	s.Called(err)
+}
+
+func (s *SpanMock) SetStatus(code trace.StatusCode, description string) {
+	s.Called(code, description)
+}
+
 func (s *SpanMock) Empty() bool {
 	return s.Called().Bool(0)
 }
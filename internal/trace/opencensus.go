@@ -8,6 +8,8 @@ import (
 
 type OpenCensusTracer struct{}
 
+var _ ErrorRecorder = openCensusSpan{}
+
 type openCensusSpan struct {
 	span *oc_trace.Span
 }
@@ -20,6 +22,35 @@ func (s openCensusSpan) AddStringAttribute(name, value string) {
 	s.span.AddAttributes(oc_trace.StringAttribute(name, value))
 }
 
+func (s openCensusSpan) AddIntAttribute(name string, value int) {
+	s.span.AddAttributes(oc_trace.Int64Attribute(name, int64(value)))
+}
+
+func (s openCensusSpan) AddBoolAttribute(name string, value bool) {
+	s.span.AddAttributes(oc_trace.BoolAttribute(name, value))
+}
+
+// RecordError adds the error as an annotation, OpenCensus has no notion of span errors.
+func (s openCensusSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.span.Annotate([]oc_trace.Attribute{
+		oc_trace.StringAttribute("error.message", err.Error()),
+	}, "error")
+}
+
+func (s openCensusSpan) SetStatus(code StatusCode, description string) {
+	switch code {
+	case StatusError:
+		s.span.SetStatus(oc_trace.Status{Code: oc_trace.StatusCodeUnknown, Message: description})
+	case StatusOK:
+		s.span.SetStatus(oc_trace.Status{Code: oc_trace.StatusCodeOK, Message: description})
+	case StatusUnset:
+	}
+}
+
 func (s openCensusSpan) Empty() bool {
 	return false
 }
@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	otel_attribute "go.opentelemetry.io/otel/attribute"
+	otel_codes "go.opentelemetry.io/otel/codes"
+	otel_trace "go.opentelemetry.io/otel/trace"
+)
+
+const openTelemetryInstrumentationName = "github.com/SKF/go-enlight-middleware"
+
+// OpenTelemetryTracer creates middleware spans using OpenTelemetry. If no
+// TracerProvider is set the global provider from otel.GetTracerProvider is used.
+type OpenTelemetryTracer struct {
+	TracerProvider otel_trace.TracerProvider
+}
+
+var _ ErrorRecorder = openTelemetrySpan{}
+
+type openTelemetrySpan struct {
+	span otel_trace.Span
+}
+
+func (s openTelemetrySpan) End() {
+	s.span.End()
+}
+
+func (s openTelemetrySpan) AddStringAttribute(name, value string) {
+	s.span.SetAttributes(otel_attribute.String(name, value))
+}
+
+func (s openTelemetrySpan) AddIntAttribute(name string, value int) {
+	s.span.SetAttributes(otel_attribute.Int(name, value))
+}
+
+func (s openTelemetrySpan) AddBoolAttribute(name string, value bool) {
+	s.span.SetAttributes(otel_attribute.Bool(name, value))
+}
+
+func (s openTelemetrySpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+}
+
+func (s openTelemetrySpan) SetStatus(code StatusCode, description string) {
+	switch code {
+	case StatusError:
+		s.span.SetStatus(otel_codes.Error, description)
+	case StatusOK:
+		s.span.SetStatus(otel_codes.Ok, description)
+	case StatusUnset:
+	}
+}
+
+func (s openTelemetrySpan) Empty() bool {
+	return false
+}
+
+func (s openTelemetrySpan) Internal() any {
+	return s.span
+}
+
+func (t *OpenTelemetryTracer) StartSpan(ctx context.Context, resourceName string) (context.Context, Span) {
+	// Same as for OpenCensus, only create middleware spans when the request is already traced.
+	if emptySpan := t.SpanFromContext(ctx); emptySpan.Empty() {
+		return ctx, emptySpan
+	}
+
+	ctx, span := t.tracer().Start(ctx, "Middleware/"+resourceName)
+
+	return ctx, openTelemetrySpan{span: span}
+}
+
+func (t *OpenTelemetryTracer) SpanFromContext(ctx context.Context) Span {
+	span := otel_trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return new(NilSpan)
+	}
+
+	return openTelemetrySpan{span: span}
+}
+
+func (t *OpenTelemetryTracer) tracer() otel_trace.Tracer {
+	provider := t.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return provider.Tracer(openTelemetryInstrumentationName)
+}
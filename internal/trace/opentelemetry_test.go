@@ -0,0 +1,88 @@
+package trace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	otel_codes "go.opentelemetry.io/otel/codes"
+	otel_sdk_trace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	otel_trace "go.opentelemetry.io/otel/trace"
+
+	"github.com/SKF/go-enlight-middleware/internal/trace"
+)
+
+func newOpenTelemetryRecorder() (*tracetest.SpanRecorder, *otel_sdk_trace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel_sdk_trace.NewTracerProvider(otel_sdk_trace.WithSpanProcessor(recorder))
+
+	return recorder, provider
+}
+
+func TestOpenTelemetry_RootSpanFromContext(t *testing.T) {
+	_, provider := newOpenTelemetryRecorder()
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+
+	span := (&trace.OpenTelemetryTracer{TracerProvider: provider}).SpanFromContext(ctx)
+
+	require.Same(t, root, span.Internal())
+}
+
+func TestOpenTelemetry_SpanFromNilContext(t *testing.T) {
+	span := new(trace.OpenTelemetryTracer).SpanFromContext(context.Background())
+
+	require.IsType(t, new(trace.NilSpan), span)
+}
+
+func TestOpenTelemetry_StartSpanNilSpan(t *testing.T) {
+	recorder, provider := newOpenTelemetryRecorder()
+
+	_, span := (&trace.OpenTelemetryTracer{TracerProvider: provider}).StartSpan(context.Background(), "A")
+
+	require.IsType(t, new(trace.NilSpan), span)
+	require.Empty(t, recorder.Started())
+}
+
+func TestOpenTelemetry_StartSpan(t *testing.T) {
+	recorder, provider := newOpenTelemetryRecorder()
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+
+	_, span := (&trace.OpenTelemetryTracer{TracerProvider: provider}).StartSpan(ctx, "A")
+	span.AddStringAttribute("key", "value")
+	span.End()
+
+	require.Implements(t, (*otel_trace.Span)(nil), span.Internal())
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 1)
+
+	require.Equal(t, "Middleware/A", ended[0].Name())
+	require.Equal(t, root.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	require.Equal(t, "value", ended[0].Attributes()[0].Value.AsString())
+}
+
+func TestOpenTelemetry_SpanErrorAndStatus(t *testing.T) {
+	recorder, provider := newOpenTelemetryRecorder()
+
+	ctx, _ := provider.Tracer("test").Start(context.Background(), "root")
+
+	_, span := (&trace.OpenTelemetryTracer{TracerProvider: provider}).StartSpan(ctx, "A")
+	errorRecorder := span.(trace.ErrorRecorder)
+	errorRecorder.AddIntAttribute("int", 1)
+	errorRecorder.AddBoolAttribute("bool", true)
+	errorRecorder.RecordError(errors.New("boom"))
+	errorRecorder.SetStatus(trace.StatusError, "failed")
+	span.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 1)
+
+	require.Equal(t, otel_codes.Error, ended[0].Status().Code)
+	require.Equal(t, "failed", ended[0].Status().Description)
+	require.Len(t, ended[0].Events(), 1)
+	require.Equal(t, "exception", ended[0].Events()[0].Name)
+}
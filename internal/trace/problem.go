@@ -0,0 +1,35 @@
+package trace
+
+import (
+	"strconv"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+const (
+	ProblemTypeKey   = "problem.type"
+	ProblemStatusKey = "problem.status"
+)
+
+// RecordProblem marks the span as failed and attaches the type and HTTP status
+// of the problem that err will be rendered as by problems.WriteResponse. Spans
+// not implementing ErrorRecorder only get the type and status as strings.
+func RecordProblem(span Span, err error) {
+	if err == nil {
+		return
+	}
+
+	problem := problems.FromError(err)
+
+	span.AddStringAttribute(ProblemTypeKey, problem.ProblemType())
+
+	recorder, ok := span.(ErrorRecorder)
+	if !ok {
+		span.AddStringAttribute(ProblemStatusKey, strconv.Itoa(problem.ProblemStatus()))
+		return
+	}
+
+	recorder.AddIntAttribute(ProblemStatusKey, problem.ProblemStatus())
+	recorder.RecordError(err)
+	recorder.SetStatus(StatusError, problem.ProblemTitle())
+}
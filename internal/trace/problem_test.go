@@ -0,0 +1,82 @@
+package trace_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/internal/trace"
+)
+
+func TestRecordProblem(t *testing.T) {
+	problem := problems.BasicProblem{
+		Type:   "/problems/teapot",
+		Title:  "I'm a teapot.",
+		Status: http.StatusTeapot,
+	}
+
+	span := new(SpanMock)
+	span.On("AddStringAttribute", trace.ProblemTypeKey, "/problems/teapot").Once()
+	span.On("AddIntAttribute", trace.ProblemStatusKey, http.StatusTeapot).Once()
+	span.On("RecordError", problem).Once()
+	span.On("SetStatus", trace.StatusError, "I'm a teapot.").Once()
+
+	trace.RecordProblem(span, problem)
+
+	span.AssertExpectations(t)
+}
+
+func TestRecordProblem_InternalError(t *testing.T) {
+	err := errors.New("boom")
+
+	span := new(SpanMock)
+	span.On("AddStringAttribute", trace.ProblemTypeKey, "/problems/internal-server-error").Once()
+	span.On("AddIntAttribute", trace.ProblemStatusKey, http.StatusInternalServerError).Once()
+	span.On("RecordError", err).Once()
+	span.On("SetStatus", trace.StatusError, "Internal Server Error").Once()
+
+	trace.RecordProblem(span, err)
+
+	span.AssertExpectations(t)
+}
+
+// stringSpan implements Span without ErrorRecorder, like spans of other modules.
+type stringSpan struct {
+	attributes map[string]string
+}
+
+func (s *stringSpan) End() {}
+
+func (s *stringSpan) AddStringAttribute(name, value string) {
+	s.attributes[name] = value
+}
+
+func (s *stringSpan) Empty() bool {
+	return false
+}
+
+func (s *stringSpan) Internal() any {
+	return s
+}
+
+func TestRecordProblem_WithoutErrorRecorder(t *testing.T) {
+	span := &stringSpan{attributes: map[string]string{}}
+
+	trace.RecordProblem(span, problems.Generic(http.StatusNotFound))
+
+	require.Equal(t, map[string]string{
+		trace.ProblemTypeKey:   "about:blank",
+		trace.ProblemStatusKey: "404",
+	}, span.attributes)
+}
+
+func TestRecordProblem_NoError(t *testing.T) {
+	span := new(SpanMock)
+
+	trace.RecordProblem(span, nil)
+
+	span.AssertExpectations(t)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import "github.com/SKF/go-enlight-middleware/internal/metrics"
+
+type (
+	// Metrics records how the middlewares handle requests, see the prometheus
+	// package for an implementation.
+	Metrics    = metrics.Metrics
+	NilMetrics = metrics.NilMetrics
+	Outcome    = metrics.Outcome
+)
+
+const (
+	OutcomeAllowed    = metrics.OutcomeAllowed
+	OutcomeSkipped    = metrics.OutcomeSkipped
+	OutcomeRejected   = metrics.OutcomeRejected
+	OutcomeError      = metrics.OutcomeError
+	OutcomeCanceled   = metrics.OutcomeCanceled
+	OutcomeRedirected = metrics.OutcomeRedirected
+
+	OperationVerification = metrics.OperationVerification
+	OperationAuthorizer   = metrics.OperationAuthorizer
+	OperationStoreLookup  = metrics.OperationStoreLookup
+)
+
+var DefaultMetrics Metrics = NilMetrics{}
@@ -0,0 +1,88 @@
+// Package prometheus implements the metrics of the middlewares using Prometheus.
+package prometheus
+
+import (
+	"time"
+
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+)
+
+const DefaultNamespace = "enlight_middleware"
+
+// DefaultBuckets are suited for the latency of token verification, authorizer
+// calls and store lookups, ranging from 0.5ms to 5s.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// Metrics counts the requests handled by the middlewares, labelled by middleware,
+// route template, outcome and problem type, and records the durations of their
+// operations, labelled by middleware, operation and route template.
+type Metrics struct {
+	requests  *prometheus_client.CounterVec
+	durations *prometheus_client.HistogramVec
+}
+
+var _ middleware.Metrics = (*Metrics)(nil)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+type Option func(*config)
+
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+func WithBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// New returns Metrics registered with registerer, or with the default registerer
+// if it is nil. New panics if the metrics are already registered.
+func New(registerer prometheus_client.Registerer, opts ...Option) *Metrics {
+	c := config{
+		namespace: DefaultNamespace,
+		buckets:   DefaultBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if registerer == nil {
+		registerer = prometheus_client.DefaultRegisterer
+	}
+
+	m := &Metrics{
+		requests: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests handled by the middleware, by outcome and problem type.",
+		}, []string{"middleware", "route", "outcome", "problem_type"}),
+		durations: prometheus_client.NewHistogramVec(prometheus_client.HistogramOpts{
+			Namespace: c.namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of the operations of the middleware, e.g. token verification or authorizer calls.",
+			Buckets:   c.buckets,
+		}, []string{"middleware", "operation", "route"}),
+	}
+
+	registerer.MustRegister(m.requests, m.durations)
+
+	return m
+}
+
+func (m *Metrics) CountRequest(name, route string, outcome middleware.Outcome, problemType string) {
+	m.requests.WithLabelValues(name, route, string(outcome), problemType).Inc()
+}
+
+func (m *Metrics) ObserveDuration(name, operation, route string, duration time.Duration) {
+	m.durations.WithLabelValues(name, operation, route).Observe(duration.Seconds())
+}
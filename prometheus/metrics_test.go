@@ -0,0 +1,53 @@
+package prometheus_test
+
+import (
+	"testing"
+	"time"
+
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/prometheus"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus_client.NewRegistry()
+	m := prometheus.New(registry, prometheus.WithNamespace("test"))
+
+	m.CountRequest("authentication", "/nodes/{nodeId}", middleware.OutcomeRejected, "/problems/missing-authentication-token")
+	m.CountRequest("authentication", "/nodes/{nodeId}", middleware.OutcomeRejected, "/problems/missing-authentication-token")
+	m.ObserveDuration("authorization", middleware.OperationAuthorizer, "/nodes/{nodeId}", 20*time.Millisecond)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+
+	durations, requests := families[0], families[1]
+
+	require.Equal(t, "test_operation_duration_seconds", durations.GetName())
+	require.EqualValues(t, 1, durations.GetMetric()[0].GetHistogram().GetSampleCount())
+	require.InDelta(t, 0.02, durations.GetMetric()[0].GetHistogram().GetSampleSum(), 0.0001)
+
+	require.Equal(t, "test_requests_total", requests.GetName())
+	require.InDelta(t, 2, requests.GetMetric()[0].GetCounter().GetValue(), 0)
+
+	labels := map[string]string{}
+	for _, label := range requests.GetMetric()[0].GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+
+	require.Equal(t, map[string]string{
+		"middleware":   "authentication",
+		"route":        "/nodes/{nodeId}",
+		"outcome":      "rejected",
+		"problem_type": "/problems/missing-authentication-token",
+	}, labels)
+}
+
+func TestNew_AlreadyRegistered(t *testing.T) {
+	registry := prometheus_client.NewRegistry()
+	prometheus.New(registry)
+
+	require.Panics(t, func() { prometheus.New(registry) })
+}
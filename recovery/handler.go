@@ -3,31 +3,139 @@ package recovery
 import (
 	"fmt"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/SKF/go-rest-utility/problems"
+	"github.com/SKF/go-utility/v2/log"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
+	"github.com/SKF/go-enlight-middleware/internal/response"
 )
 
-type Middleware struct{}
+// StackKey is the span attribute containing the stack of the recovered panic.
+const StackKey = "error.stack"
+
+const metricsName = "recovery"
+
+// PanicLogger logs a recovered panic, stack is the stack of the panicking goroutine.
+type PanicLogger func(r *http.Request, recovered any, stack []byte)
+
+// ProblemFactory returns the problem written as response to a recovered panic.
+type ProblemFactory func(r *http.Request, recovered any) error
+
+type Middleware struct {
+	Tracer  middleware.Tracer
+	Metrics middleware.Metrics
+
+	logger         PanicLogger
+	problemFactory ProblemFactory
+}
+
+// New returns a new recovery middleware, which recovers panics by logging them,
+// recording them on the span of the request and responding with an InternalProblem.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		Tracer:  middleware.DefaultTracer,
+		Metrics: middleware.DefaultMetrics,
+
+		logger:         DefaultPanicLogger,
+		problemFactory: DefaultProblemFactory,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
 
-func New() *Middleware {
-	return &Middleware{}
+	return m
+}
+
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageRecovery
 }
 
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := response.NewWriter(w)
+
 			defer func() {
-				if panic := recover(); panic != nil {
-					err, ok := panic.(error)
-					if !ok {
-						err = fmt.Errorf("panic: %v", panic)
-					}
+				recovered := recover()
+				if recovered == nil {
+					metrics.CountOutcome(m.Metrics, metricsName, r, middleware.OutcomeAllowed)
+					return
+				}
 
-					problems.WriteResponse(r.Context(), err, w, r)
+				// ErrAbortHandler is used to abort a response on purpose, and is
+				// handled silently by the http.Server.
+				if recovered == http.ErrAbortHandler { //nolint:errorlint
+					panic(recovered)
 				}
+
+				m.recover(rw, r, recovered, debug.Stack())
 			}()
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(rw, r)
 		})
 	}
 }
+
+func (m *Middleware) recover(w *response.Writer, r *http.Request, recovered any, stack []byte) {
+	ctx := r.Context()
+
+	var err error
+	if m.problemFactory != nil {
+		err = m.problemFactory(r, recovered)
+	}
+
+	if err == nil {
+		err = DefaultProblemFactory(r, recovered)
+	}
+
+	// The zero value Middleware must not panic while recovering.
+	tracer := m.Tracer
+	if tracer == nil {
+		tracer = middleware.DefaultTracer
+	}
+
+	span := tracer.SpanFromContext(ctx)
+	span.AddStringAttribute(StackKey, string(stack))
+	middleware.RecordProblem(span, err)
+	metrics.CountRequest(m.Metrics, metricsName, r, err)
+
+	if m.logger != nil {
+		m.logger(r, recovered, stack)
+	}
+
+	if w.HeaderWritten() {
+		// The response has already started, writing a problem would corrupt it.
+		// Aborting makes the client aware that the response is incomplete.
+		panic(http.ErrAbortHandler)
+	}
+
+	problems.WriteResponse(ctx, err, w, r)
+}
+
+// DefaultPanicLogger logs the panic and its stack as an error.
+func DefaultPanicLogger(r *http.Request, recovered any, stack []byte) {
+	log.WithTracing(r.Context()).
+		WithError(panicError(recovered)).
+		WithField("stack", string(stack)).
+		WithField("method", r.Method).
+		WithField("url", r.URL.String()).
+		Error("Recovered from panic in RecoveryMiddleware")
+}
+
+// DefaultProblemFactory returns an InternalProblem, which does not expose the panic to the client.
+func DefaultProblemFactory(_ *http.Request, recovered any) error {
+	return problems.Internal(panicError(recovered))
+}
+
+func panicError(recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("panic: %v", recovered)
+}
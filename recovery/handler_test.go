@@ -1,6 +1,7 @@
 package recovery
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,13 @@ import (
 
 	"github.com/SKF/go-rest-utility/problems"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otel_codes "go.opentelemetry.io/otel/codes"
+	otel_sdk_trace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/internal/metrics"
 )
 
 func TestPanicOutputsAnInternalProblem(t *testing.T) {
@@ -35,3 +43,194 @@ func TestPanicOutputsAnInternalProblem(t *testing.T) {
 	require.NotContains(t, problem.Type, panicValue, "Do not leak panic information")
 	require.NotContains(t, problem.Detail, panicValue, "Do not leak panic information")
 }
+
+func TestPanicIsLoggedWithStack(t *testing.T) {
+	var (
+		loggedRequest   *http.Request
+		loggedRecovered any
+		loggedStack     []byte
+	)
+
+	middleware := New(WithPanicLogger(func(r *http.Request, recovered any, stack []byte) {
+		loggedRequest, loggedRecovered, loggedStack = r, recovered, stack
+	})).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+	middleware(endpoint).ServeHTTP(httptest.NewRecorder(), request)
+
+	require.Same(t, request, loggedRequest)
+	require.Equal(t, "boom", loggedRecovered)
+	require.Contains(t, string(loggedStack), "TestPanicIsLoggedWithStack")
+}
+
+func TestPanicIsRecordedOnSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel_sdk_trace.NewTracerProvider(otel_sdk_trace.WithSpanProcessor(recorder))
+
+	mw := New(WithPanicLogger(nil))
+	mw.Tracer = &middleware.OpenTelemetryTracer{TracerProvider: provider}
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+	request := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	mw.Middleware()(endpoint).ServeHTTP(httptest.NewRecorder(), request)
+	root.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	require.Equal(t, otel_codes.Error, spans[0].Status().Code)
+	require.Contains(t, spans[0].Attributes(), attribute.String(middleware.ProblemTypeKey, "/problems/internal-server-error"))
+
+	var stack string
+
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == StackKey {
+			stack = attr.Value.AsString()
+		}
+	}
+
+	require.Contains(t, stack, "TestPanicIsRecordedOnSpan")
+}
+
+func TestWithProblemFactory(t *testing.T) {
+	middleware := New(
+		WithPanicLogger(nil),
+		WithProblemFactory(func(r *http.Request, recovered any) error {
+			return problems.BasicProblem{
+				Type:   "/problems/service-unavailable",
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+			}
+		}),
+	).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	middleware(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var problem problems.BasicProblem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "/problems/service-unavailable", problem.ProblemType())
+}
+
+func TestWithProblemFactory_Nil(t *testing.T) {
+	middleware := New(WithPanicLogger(nil), WithProblemFactory(nil)).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	middleware(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestZeroValueMiddleware(t *testing.T) {
+	middleware := (&Middleware{}).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	middleware(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHijackerIsForwarded(t *testing.T) {
+	var hijackable bool
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, hijackable = w.(http.Hijacker)
+	})
+
+	server := httptest.NewServer(New().Middleware()(endpoint))
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.True(t, hijackable)
+}
+
+func TestErrAbortHandlerIsRepanicked(t *testing.T) {
+	logged := false
+
+	middleware := New(WithPanicLogger(func(*http.Request, any, []byte) { logged = true })).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	w := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		middleware(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	require.False(t, logged)
+	require.Empty(t, w.Body.String())
+}
+
+func TestPanicAfterResponseStarted(t *testing.T) {
+	logged := false
+
+	middleware := New(WithPanicLogger(func(*http.Request, any, []byte) { logged = true })).Middleware()
+
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("partial")) //nolint:errcheck
+		w.(http.Flusher).Flush()
+
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		middleware(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	require.True(t, logged)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "partial", w.Body.String())
+	require.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+}
+
+func TestMetrics(t *testing.T) {
+	recorder := new(metrics.Recorder)
+
+	m := New(WithPanicLogger(nil))
+	m.Metrics = recorder
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /panic", m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})))
+	serveMux.Handle("GET /ok", m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})))
+
+	serveMux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	serveMux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	require.Equal(t, []metrics.Request{
+		{Middleware: "recovery", Route: "GET /ok", Outcome: metrics.OutcomeAllowed},
+		{Middleware: "recovery", Route: "GET /panic", Outcome: metrics.OutcomeError, ProblemType: "/problems/internal-server-error"},
+	}, recorder.Requests())
+}
@@ -0,0 +1,20 @@
+package recovery
+
+type Option func(*Middleware)
+
+// WithPanicLogger replaces DefaultPanicLogger, a nil logger disables logging.
+func WithPanicLogger(logger PanicLogger) Option {
+	return func(m *Middleware) {
+		m.logger = logger
+	}
+}
+
+// WithProblemFactory replaces DefaultProblemFactory, e.g. to return a custom
+// problem. A nil factory keeps DefaultProblemFactory.
+func WithProblemFactory(factory ProblemFactory) Option {
+	return func(m *Middleware) {
+		if factory != nil {
+			m.problemFactory = factory
+		}
+	}
+}
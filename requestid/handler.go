@@ -0,0 +1,92 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+)
+
+const (
+	DefaultHeader string = "X-Request-ID"
+
+	// SpanAttributeKey is the attribute of the active span containing the request ID.
+	SpanAttributeKey string = "request.id"
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:+/=-]{1,128}$`)
+
+type Middleware struct {
+	Tracer middleware.Tracer
+
+	header          string
+	generate        func() string
+	validate        func(string) bool
+	problemInstance bool
+}
+
+// New returns a new request ID middleware, which reuses a valid request ID
+// provided in the request header "X-Request-ID" or generates a UUID.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		Tracer: middleware.DefaultTracer,
+
+		header:   DefaultHeader,
+		generate: uuid.NewString,
+		validate: validRequestID.MatchString,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageRequestID
+}
+
+func (m *Middleware) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			id := r.Header.Get(m.header)
+			if !m.validate(id) {
+				id = m.generate()
+			}
+
+			m.Tracer.SpanFromContext(ctx).AddStringAttribute(SpanAttributeKey, id)
+			w.Header().Set(m.header, id)
+
+			r = r.WithContext(NewContext(ctx, id))
+
+			if !m.problemInstance {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			pw := &problemWriter{ResponseWriter: w, instance: id}
+			defer pw.finish()
+
+			next.ServeHTTP(pw, r)
+		})
+	}
+}
+
+type requestIDContextKey struct{}
+
+func NewContext(parent context.Context, id string) context.Context {
+	return context.WithValue(parent, requestIDContextKey{}, id)
+}
+
+// FromContext returns the request ID of the request.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
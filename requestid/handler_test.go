@@ -0,0 +1,194 @@
+package requestid_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otel_sdk_trace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/requestid"
+)
+
+func doRequest(mw *requestid.Middleware, r *http.Request, endpoint http.HandlerFunc) (*httptest.ResponseRecorder, string) {
+	var fromContext string
+
+	handler := mw.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext, _ = requestid.FromContext(r.Context())
+
+		if endpoint != nil {
+			endpoint(w, r)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w, fromContext
+}
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		provided string
+		reused   bool
+	}{
+		{desc: "valid id is reused", provided: "3f1c7e0e-0a4b-4b8e-9d2a-4c5e1f2a3b4c", reused: true},
+		{desc: "opaque id is reused", provided: "Root=1-67891233-abcdef012345678912345678", reused: true},
+		{desc: "missing id is generated"},
+		{desc: "invalid id is replaced", provided: "<script>"},
+		{desc: "too long id is replaced", provided: strings.Repeat("a", 129)},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tC.provided != "" {
+				r.Header.Set(requestid.DefaultHeader, tC.provided)
+			}
+
+			w, fromContext := doRequest(requestid.New(), r, nil)
+
+			id := w.Header().Get(requestid.DefaultHeader)
+			require.Equal(t, id, fromContext)
+
+			if tC.reused {
+				require.Equal(t, tC.provided, id)
+			} else {
+				_, err := uuid.Parse(id)
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithHeaderAndGenerator(t *testing.T) {
+	mw := requestid.New(
+		requestid.WithHeader("X-Correlation-ID"),
+		requestid.WithGenerator(func() string { return "generated" }),
+		requestid.WithValidator(func(id string) bool { return strings.HasPrefix(id, "req-") }),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Correlation-ID", "req-1")
+
+	w, _ := doRequest(mw, r, nil)
+	require.Equal(t, "req-1", w.Header().Get("X-Correlation-ID"))
+	require.Empty(t, w.Header().Get(requestid.DefaultHeader))
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Correlation-ID", "other")
+
+	w, _ = doRequest(mw, r, nil)
+	require.Equal(t, "generated", w.Header().Get("X-Correlation-ID"))
+}
+
+func TestRequestIDIsAddedToSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel_sdk_trace.NewTracerProvider(otel_sdk_trace.WithSpanProcessor(recorder))
+
+	mw := requestid.New()
+	mw.Tracer = &middleware.OpenTelemetryTracer{TracerProvider: provider}
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header.Set(requestid.DefaultHeader, "req-1")
+
+	doRequest(mw, r, nil)
+	root.End()
+
+	require.Len(t, recorder.Ended(), 1)
+	require.Contains(t, recorder.Ended()[0].Attributes(), attribute.String(requestid.SpanAttributeKey, "req-1"))
+}
+
+func TestWithProblemInstance(t *testing.T) {
+	mw := requestid.New(requestid.WithProblemInstance())
+
+	r := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+	r.Header.Set(requestid.DefaultHeader, "req-1")
+
+	w, _ := doRequest(mw, r, func(w http.ResponseWriter, r *http.Request) {
+		problems.WriteResponse(r.Context(), problems.Generic(http.StatusNotFound), w, r)
+	})
+
+	var problem problems.BasicProblem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, problems.ContentType, w.Header().Get("Content-Type"))
+	require.Equal(t, "req-1", problem.Instance)
+	require.Equal(t, http.StatusNotFound, problem.Status)
+}
+
+func TestWithProblemInstance_OtherResponses(t *testing.T) {
+	mw := requestid.New(requestid.WithProblemInstance())
+
+	w, _ := doRequest(mw, httptest.NewRequest(http.MethodGet, "/", nil), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"instance":"kept"}`)) //nolint:errcheck
+	})
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.JSONEq(t, `{"instance":"kept"}`, w.Body.String())
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+
+	conn net.Conn
+}
+
+func (r hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, nil, nil
+}
+
+func TestWithProblemInstance_Hijack(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	var conn net.Conn
+
+	handler := requestid.New(requestid.WithProblemInstance()).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		require.True(t, ok, "websocket libraries assert http.Hijacker directly")
+
+		var err error
+
+		conn, _, err = hijacker.Hijack()
+		require.NoError(t, err)
+	}))
+
+	handler.ServeHTTP(hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Same(t, server, conn)
+}
+
+func TestWithProblemInstance_ReadFrom(t *testing.T) {
+	mw := requestid.New(requestid.WithProblemInstance())
+
+	var readerFrom bool
+
+	w, _ := doRequest(mw, httptest.NewRequest(http.MethodGet, "/", nil), func(w http.ResponseWriter, r *http.Request) {
+		_, readerFrom = w.(io.ReaderFrom)
+
+		io.Copy(w, strings.NewReader("hello")) //nolint:errcheck
+	})
+
+	require.True(t, readerFrom)
+	require.Equal(t, "hello", w.Body.String())
+}
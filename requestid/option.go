@@ -0,0 +1,32 @@
+package requestid
+
+type Option func(*Middleware)
+
+// WithHeader reads and writes the request ID using the header instead of "X-Request-ID".
+func WithHeader(header string) Option {
+	return func(m *Middleware) {
+		m.header = header
+	}
+}
+
+// WithGenerator replaces the generation of UUIDs for requests without a valid request ID.
+func WithGenerator(generate func() string) Option {
+	return func(m *Middleware) {
+		m.generate = generate
+	}
+}
+
+// WithValidator replaces the validation of provided request IDs, which by default
+// accepts up to 128 alphanumeric characters and "._:+/=-".
+func WithValidator(validate func(id string) bool) Option {
+	return func(m *Middleware) {
+		m.validate = validate
+	}
+}
+
+// WithProblemInstance sets the "instance" member of problem responses to the request ID.
+func WithProblemInstance() Option {
+	return func(m *Middleware) {
+		m.problemInstance = true
+	}
+}
@@ -0,0 +1,132 @@
+package requestid
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SKF/go-rest-utility/problems"
+)
+
+// problemWriter buffers problem responses, written by problems.WriteResponse or
+// otherwise, to replace their "instance" member with the request ID.
+type problemWriter struct {
+	http.ResponseWriter
+
+	instance    string
+	wroteHeader bool
+	buffering   bool
+	status      int
+	body        bytes.Buffer
+}
+
+func (w *problemWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	if status < http.StatusOK && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.wroteHeader = true
+
+	if strings.HasPrefix(w.Header().Get("Content-Type"), problems.ContentType) {
+		w.buffering = true
+		w.status = status
+
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *problemWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.buffering {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *problemWriter) Flush() {
+	if w.buffering {
+		return
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ReadFrom allows the underlying writer to optimize copying, e.g. using sendfile.
+func (w *problemWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.buffering {
+		return w.body.ReadFrom(r)
+	}
+
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(r)
+	}
+
+	// Hides ReadFrom of the problemWriter from io.Copy, which would otherwise recurse.
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+// Hijack allows handlers to take over the connection, e.g. for WebSockets.
+func (w *problemWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T does not implement http.Hijacker", http.ErrNotSupported, w.ResponseWriter)
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		// Nothing can be written through the problemWriter once hijacked.
+		w.wroteHeader = true
+		w.buffering = false
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *problemWriter) finish() {
+	if !w.buffering {
+		return
+	}
+
+	body := w.body.Bytes()
+
+	var problem map[string]any
+	if err := json.Unmarshal(body, &problem); err == nil {
+		problem["instance"] = w.instance
+
+		if encoded, err := json.Marshal(problem); err == nil {
+			body = append(encoded, '\n')
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body) //nolint:errcheck
+}
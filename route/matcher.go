@@ -0,0 +1,187 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Matcher decides if a request belongs to a route, it is used by the middlewares
+// to configure per-route behaviour.
+type Matcher interface {
+	Match(r *http.Request) bool
+}
+
+// MatcherFunc is an arbitrary predicate on the request.
+type MatcherFunc func(r *http.Request) bool
+
+func (f MatcherFunc) Match(r *http.Request) bool {
+	return f(r)
+}
+
+// All matches requests which are matched by all of its matchers.
+type All []Matcher
+
+func (ms All) Match(r *http.Request) bool {
+	for _, m := range ms {
+		if !m.Match(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Any matches requests which are matched by at least one of its matchers.
+type Any []Matcher
+
+func (ms Any) Match(r *http.Request) bool {
+	for _, m := range ms {
+		if m.Match(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type muxRoute struct {
+	route *mux.Route
+}
+
+// Mux matches requests routed to the given gorilla/mux route.
+func Mux(route *mux.Route) Matcher {
+	return muxRoute{route: route}
+}
+
+func (m muxRoute) Match(r *http.Request) bool {
+	return m.route != nil && currentMuxRoute(r) == m.route
+}
+
+type pattern string
+
+// Pattern matches requests routed to the route registered with the pattern,
+// e.g. "GET /nodes/{nodeId}" for http.ServeMux or "/nodes/{nodeId}" for
+// gorilla/mux. The route is resolved by the Router added through Middleware.
+func Pattern(p string) Matcher {
+	return pattern(p)
+}
+
+func (p pattern) Match(r *http.Request) bool {
+	resolved, found := Resolve(r)
+	return found && resolved == string(p)
+}
+
+type methods []string
+
+// Methods matches requests using any of the given HTTP methods.
+func Methods(ms ...string) Matcher {
+	normalized := make(methods, 0, len(ms))
+
+	for _, m := range ms {
+		normalized = append(normalized, strings.ToUpper(m))
+	}
+
+	return normalized
+}
+
+func (ms methods) Match(r *http.Request) bool {
+	return slices.Contains(ms, r.Method)
+}
+
+type pathPrefix string
+
+// PathPrefix matches requests where the URL path starts with prefix.
+func PathPrefix(prefix string) Matcher {
+	return pathPrefix(prefix)
+}
+
+func (p pathPrefix) Match(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, string(p))
+}
+
+type pathTemplate struct {
+	template string
+	regexp   *regexp.Regexp
+}
+
+// Path matches requests where the URL path matches the template. The template
+// uses the gorilla/mux syntax, where "{name}" matches a single path segment and
+// "{name:pattern}" a regular expression. The net/http syntax "{name...}",
+// matching the remainder of the path, is supported as well.
+//
+// Path panics if the template is invalid.
+func Path(template string) Matcher {
+	re, err := compileTemplate(template)
+	if err != nil {
+		panic(fmt.Sprintf("route: invalid path template %q: %v", template, err))
+	}
+
+	return pathTemplate{template: template, regexp: re}
+}
+
+func (p pathTemplate) Match(r *http.Request) bool {
+	return p.regexp.MatchString(r.URL.Path)
+}
+
+func compileTemplate(template string) (*regexp.Regexp, error) {
+	pattern := new(strings.Builder)
+	pattern.WriteString("^")
+
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+
+		end, err := closingBrace(rest, start)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+		pattern.WriteString(variablePattern(rest[start+1 : end]))
+
+		rest = rest[end+1:]
+	}
+
+	pattern.WriteString("$")
+
+	return regexp.Compile(pattern.String())
+}
+
+// closingBrace returns the index of the brace closing the one at start,
+// braces may be nested inside of regular expressions.
+func closingBrace(s string, start int) (int, error) {
+	depth := 0
+
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unbalanced braces at offset %d", start)
+}
+
+func variablePattern(variable string) string {
+	if _, pattern, found := strings.Cut(variable, ":"); found {
+		return "(?:" + pattern + ")"
+	}
+
+	if strings.HasSuffix(variable, "...") {
+		return ".*"
+	}
+
+	return "[^/]+"
+}
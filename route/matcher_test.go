@@ -0,0 +1,111 @@
+package route_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+func TestPath(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		expected bool
+	}{
+		{template: "/nodes", path: "/nodes", expected: true},
+		{template: "/nodes", path: "/nodes/", expected: false},
+		{template: "/nodes/{nodeId}", path: "/nodes/123", expected: true},
+		{template: "/nodes/{nodeId}", path: "/nodes/123/children", expected: false},
+		{template: "/nodes/{nodeId}", path: "/nodes/", expected: false},
+		{template: "/nodes/{nodeId:[0-9]+}", path: "/nodes/123", expected: true},
+		{template: "/nodes/{nodeId:[0-9]+}", path: "/nodes/abc", expected: false},
+		{template: "/nodes/{nodeId:[0-9]{2}}", path: "/nodes/12", expected: true},
+		{template: "/nodes/{nodeId:[0-9]{2}}", path: "/nodes/123", expected: false},
+		{template: "/files/{path...}", path: "/files/a/b/c", expected: true},
+		{template: "/a.b", path: "/aXb", expected: false},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.template+" "+tC.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tC.path, nil)
+
+			require.Equal(t, tC.expected, route.Path(tC.template).Match(r))
+		})
+	}
+}
+
+func TestPath_InvalidTemplate(t *testing.T) {
+	require.Panics(t, func() {
+		route.Path("/nodes/{nodeId")
+	})
+}
+
+func TestPathPrefix(t *testing.T) {
+	matcher := route.PathPrefix("/health")
+
+	require.True(t, matcher.Match(httptest.NewRequest(http.MethodGet, "/health/ready", nil)))
+	require.False(t, matcher.Match(httptest.NewRequest(http.MethodGet, "/nodes", nil)))
+}
+
+func TestMethods(t *testing.T) {
+	matcher := route.Methods("get", http.MethodHead)
+
+	require.True(t, matcher.Match(httptest.NewRequest(http.MethodGet, "/", nil)))
+	require.True(t, matcher.Match(httptest.NewRequest(http.MethodHead, "/", nil)))
+	require.False(t, matcher.Match(httptest.NewRequest(http.MethodPost, "/", nil)))
+}
+
+func TestAllAndAny(t *testing.T) {
+	get := httptest.NewRequest(http.MethodGet, "/nodes/1", nil)
+	post := httptest.NewRequest(http.MethodPost, "/nodes/1", nil)
+
+	all := route.All{route.Path("/nodes/{nodeId}"), route.Methods(http.MethodGet)}
+	require.True(t, all.Match(get))
+	require.False(t, all.Match(post))
+
+	anyOf := route.Any{route.PathPrefix("/health"), route.Methods(http.MethodPost)}
+	require.False(t, anyOf.Match(get))
+	require.True(t, anyOf.Match(post))
+
+	require.True(t, route.All{}.Match(get))
+	require.False(t, route.Any{}.Match(get))
+}
+
+func TestMatcherFunc(t *testing.T) {
+	matcher := route.MatcherFunc(func(r *http.Request) bool {
+		return r.Header.Get("X-Internal") == "true"
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.False(t, matcher.Match(r))
+
+	r.Header.Set("X-Internal", "true")
+	require.True(t, matcher.Match(r))
+}
+
+func TestMux(t *testing.T) {
+	var matched bool
+
+	router := mux.NewRouter()
+	a := router.Path("/a").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	b := router.Path("/b").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			matched = route.Mux(a).Match(r)
+		})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	require.True(t, matched)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b", nil))
+	require.False(t, matched)
+
+	require.False(t, route.Mux(b).Match(httptest.NewRequest(http.MethodGet, "/b", nil)), "no current route outside of the router")
+}
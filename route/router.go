@@ -0,0 +1,154 @@
+package route
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Router resolves the route a request is routed to, identified by its pattern.
+type Router interface {
+	// Route returns the pattern of the matched route, e.g. "/nodes/{nodeId}"
+	// for gorilla/mux or "GET /nodes/{nodeId}" for http.ServeMux.
+	Route(r *http.Request) (string, bool)
+}
+
+// Default resolves routes for middlewares running inside of the router, i.e.
+// added through mux.Router.Use or wrapping the handlers of a http.ServeMux.
+var Default Router = Routers{
+	GorillaRouter{},
+	ServeMuxRouter{},
+}
+
+// Routers uses the first router which is able to resolve the route.
+type Routers []Router
+
+func (rs Routers) Route(r *http.Request) (string, bool) {
+	for _, router := range rs {
+		if pattern, found := router.Route(r); found {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// GorillaRouter resolves routes of gorilla/mux. Router is only required when the
+// middlewares wrap the router instead of being added through mux.Router.Use.
+type GorillaRouter struct {
+	Router *mux.Router
+}
+
+func Gorilla(router *mux.Router) GorillaRouter {
+	return GorillaRouter{Router: router}
+}
+
+func (g GorillaRouter) Route(r *http.Request) (string, bool) {
+	route := g.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+
+	return template, true
+}
+
+// CurrentRoute returns the route the request is, or would be, routed to.
+func (g GorillaRouter) CurrentRoute(r *http.Request) *mux.Route {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route
+	}
+
+	if g.Router == nil {
+		return nil
+	}
+
+	var match mux.RouteMatch
+	if !g.Router.Match(r, &match) || match.MatchErr != nil {
+		return nil
+	}
+
+	return match.Route
+}
+
+// ServeMuxRouter resolves routes of http.ServeMux. Mux is only required when the
+// middlewares wrap the ServeMux instead of the individual handlers.
+type ServeMuxRouter struct {
+	Mux *http.ServeMux
+}
+
+func ServeMux(serveMux *http.ServeMux) ServeMuxRouter {
+	return ServeMuxRouter{Mux: serveMux}
+}
+
+func (s ServeMuxRouter) Route(r *http.Request) (string, bool) {
+	if r.Pattern != "" {
+		return r.Pattern, true
+	}
+
+	if s.Mux == nil {
+		return "", false
+	}
+
+	_, pattern := s.Mux.Handler(r)
+
+	return pattern, pattern != ""
+}
+
+type routerContextKey struct{}
+
+// Middleware makes the router available to all following middlewares, it is
+// needed when the middlewares wrap the router rather than running inside of it.
+func Middleware(router Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), router)))
+		})
+	}
+}
+
+func NewContext(parent context.Context, router Router) context.Context {
+	return context.WithValue(parent, routerContextKey{}, router)
+}
+
+// FromContext returns the router added by Middleware, or Default.
+func FromContext(ctx context.Context) Router {
+	if router, ok := ctx.Value(routerContextKey{}).(Router); ok {
+		return router
+	}
+
+	return Default
+}
+
+// Resolve returns the pattern of the route the request is routed to.
+func Resolve(r *http.Request) (string, bool) {
+	return FromContext(r.Context()).Route(r)
+}
+
+// currentMuxRoute returns the gorilla/mux route of the request, also when the
+// middlewares wrap a gorilla/mux router added through Middleware.
+func currentMuxRoute(r *http.Request) *mux.Route {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route
+	}
+
+	switch router := FromContext(r.Context()).(type) {
+	case GorillaRouter:
+		return router.CurrentRoute(r)
+	case Routers:
+		for _, candidate := range router {
+			if gorilla, ok := candidate.(GorillaRouter); ok {
+				if route := gorilla.CurrentRoute(r); route != nil {
+					return route
+				}
+			}
+		}
+	}
+
+	return nil
+}
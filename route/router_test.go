@@ -0,0 +1,102 @@
+package route_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+func resolveWith(resolved *string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*resolved, _ = route.Resolve(r)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestResolve_ServeMux(t *testing.T) {
+	var resolved string
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /nodes/{nodeId}", resolveWith(&resolved)(http.NotFoundHandler()))
+
+	serveMux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.Equal(t, "GET /nodes/{nodeId}", resolved, "middleware wrapping the handler")
+
+	resolved = ""
+	handler := route.Middleware(route.ServeMux(serveMux))(resolveWith(&resolved)(http.NotFoundHandler()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.Equal(t, "GET /nodes/{nodeId}", resolved, "middleware wrapping the ServeMux")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/assets", nil))
+	require.Empty(t, resolved, "unknown route")
+}
+
+func TestResolve_Gorilla(t *testing.T) {
+	var resolved string
+
+	router := mux.NewRouter()
+	router.Path("/nodes/{nodeId}").Methods(http.MethodGet).Handler(http.NotFoundHandler())
+	router.Use(resolveWith(&resolved))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.Equal(t, "/nodes/{nodeId}", resolved, "middleware added through Use")
+
+	resolved = ""
+	handler := route.Middleware(route.Gorilla(router))(resolveWith(&resolved)(router))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.Equal(t, "/nodes/{nodeId}", resolved, "middleware wrapping the router")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/nodes/123", nil))
+	require.Empty(t, resolved, "method not allowed")
+}
+
+func TestResolve_WithoutRouter(t *testing.T) {
+	_, found := route.Resolve(httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.False(t, found)
+}
+
+func TestPattern(t *testing.T) {
+	var matched bool
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("GET /nodes/{nodeId}", http.NotFoundHandler())
+	serveMux.Handle("/assets/", http.NotFoundHandler())
+
+	handler := route.Middleware(route.ServeMux(serveMux))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched = route.Pattern("GET /nodes/{nodeId}").Match(r)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes/123", nil))
+	require.True(t, matched)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/assets/123", nil))
+	require.False(t, matched)
+}
+
+func TestMux_WrappingRouter(t *testing.T) {
+	var matched bool
+
+	router := mux.NewRouter()
+	a := router.Path("/a").Handler(http.NotFoundHandler())
+	router.Path("/b").Handler(http.NotFoundHandler())
+
+	handler := route.Middleware(route.Gorilla(router))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched = route.Mux(a).Match(r)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	require.True(t, matched)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b", nil))
+	require.False(t, matched)
+}
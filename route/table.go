@@ -0,0 +1,42 @@
+package route
+
+import "net/http"
+
+// Table maps matchers to values, e.g. policies. The first added matcher which
+// matches a request decides the value. The zero value is an empty table.
+type Table[T any] struct {
+	entries []tableEntry[T]
+}
+
+type tableEntry[T any] struct {
+	matcher Matcher
+	value   T
+}
+
+// Set adds the matcher to the table. Setting a gorilla/mux route which is
+// already part of the table replaces its value.
+func (t *Table[T]) Set(matcher Matcher, value T) {
+	if route, ok := matcher.(muxRoute); ok {
+		for i, entry := range t.entries {
+			if existing, ok := entry.matcher.(muxRoute); ok && existing == route {
+				t.entries[i].value = value
+				return
+			}
+		}
+	}
+
+	t.entries = append(t.entries, tableEntry[T]{matcher: matcher, value: value})
+}
+
+// Lookup returns the value of the first matcher matching the request.
+func (t *Table[T]) Lookup(r *http.Request) (T, bool) {
+	for _, entry := range t.entries {
+		if entry.matcher.Match(r) {
+			return entry.value, true
+		}
+	}
+
+	var zero T
+
+	return zero, false
+}
@@ -0,0 +1,49 @@
+package route_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+func TestTable_FirstMatchWins(t *testing.T) {
+	var table route.Table[string]
+
+	table.Set(route.Path("/nodes/{nodeId}"), "node")
+	table.Set(route.PathPrefix("/nodes"), "nodes")
+
+	value, found := table.Lookup(httptest.NewRequest(http.MethodGet, "/nodes/1", nil))
+	require.True(t, found)
+	require.Equal(t, "node", value)
+
+	value, found = table.Lookup(httptest.NewRequest(http.MethodGet, "/nodes/1/children", nil))
+	require.True(t, found)
+	require.Equal(t, "nodes", value)
+
+	_, found = table.Lookup(httptest.NewRequest(http.MethodGet, "/assets", nil))
+	require.False(t, found)
+}
+
+func TestTable_ReplacesMuxRoute(t *testing.T) {
+	var (
+		table route.Table[string]
+		value string
+	)
+
+	router := mux.NewRouter()
+	a := router.Path("/a").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, _ = table.Lookup(r)
+	})
+
+	table.Set(route.Mux(a), "first")
+	table.Set(route.Mux(a), "second")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+
+	require.Equal(t, "second", value)
+}
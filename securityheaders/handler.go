@@ -0,0 +1,120 @@
+package securityheaders
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/SKF/go-rest-utility/problems"
+	"github.com/gorilla/mux"
+
+	middleware "github.com/SKF/go-enlight-middleware"
+	"github.com/SKF/go-enlight-middleware/route"
+)
+
+const nonceSize = 16
+
+type Middleware struct {
+	Tracer middleware.Tracer
+
+	policy   Policy
+	policies *route.Table[Policy]
+}
+
+// New returns a new security headers middleware. Without providing any Options
+// X-Content-Type-Options, X-Frame-Options and Referrer-Policy are set.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		Tracer: middleware.DefaultTracer,
+
+		policy:   Default,
+		policies: new(route.Table[Policy]),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// SetPolicy uses the policy, instead of the policy configured through Options,
+// for requests to the route.
+func (m *Middleware) SetPolicy(r *mux.Route, policy Policy) *Middleware {
+	return m.SetPolicyMatching(route.Mux(r), policy)
+}
+
+// SetPolicyMatching uses the policy for all requests matched by matcher. If
+// several matchers match a request, the policy set first is used.
+func (m *Middleware) SetPolicyMatching(matcher route.Matcher, policy Policy) *Middleware {
+	m.policies.Set(matcher, policy)
+
+	return m
+}
+
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageSecurityHeaders
+}
+
+func (m *Middleware) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := m.Tracer.StartSpan(r.Context(), "SecurityHeaders")
+
+			policy := m.findPolicyForRequest(ctx, r)
+
+			var nonce string
+
+			if policy.needsNonce() {
+				var err error
+				if nonce, err = generateNonce(); err != nil {
+					middleware.RecordProblem(span, err)
+					problems.WriteResponse(ctx, err, w, r)
+					span.End()
+
+					return
+				}
+
+				r = r.WithContext(context.WithValue(r.Context(), nonceContextKey{}, nonce))
+			}
+
+			policy.write(w.Header(), nonce)
+
+			span.End()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (m *Middleware) findPolicyForRequest(ctx context.Context, r *http.Request) Policy {
+	_, span := m.Tracer.StartSpan(ctx, "SecurityHeaders/findPolicyForRequest")
+	defer span.End()
+
+	if policy, found := m.policies.Lookup(r); found {
+		return policy
+	}
+
+	return m.policy
+}
+
+type nonceContextKey struct{}
+
+// NonceFromContext returns the Content-Security-Policy nonce of the request, to
+// be used in the nonce attribute of inline scripts and styles.
+func NonceFromContext(ctx context.Context) (string, bool) {
+	nonce, ok := ctx.Value(nonceContextKey{}).(string)
+	return nonce, ok
+}
+
+func generateNonce() (string, error) {
+	nonce := make([]byte, nonceSize)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(nonce), nil
+}
@@ -0,0 +1,123 @@
+package securityheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/SKF/go-enlight-middleware/route"
+	"github.com/SKF/go-enlight-middleware/securityheaders"
+)
+
+func doRequest(mw *securityheaders.Middleware, path string, endpoint http.HandlerFunc) *httptest.ResponseRecorder {
+	if endpoint == nil {
+		endpoint = func(w http.ResponseWriter, r *http.Request) {}
+	}
+
+	w := httptest.NewRecorder()
+	mw.Middleware()(endpoint).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return w
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	w := doRequest(securityheaders.New(), "/", nil)
+
+	require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	require.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	require.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+	require.NotContains(t, w.Header(), "Content-Security-Policy")
+	require.NotContains(t, w.Header(), "Cross-Origin-Opener-Policy")
+}
+
+func TestAllHeaders(t *testing.T) {
+	mw := securityheaders.New(
+		securityheaders.WithContentSecurityPolicy("default-src 'self';"),
+		securityheaders.WithFrameOptions("SAMEORIGIN"),
+		securityheaders.WithReferrerPolicy("no-referrer"),
+		securityheaders.WithPermissionsPolicy("camera=(), geolocation=()"),
+		securityheaders.WithCrossOriginOpenerPolicy("same-origin"),
+		securityheaders.WithCrossOriginEmbedderPolicy("require-corp"),
+		securityheaders.WithCrossOriginResourcePolicy("same-site"),
+		securityheaders.WithoutContentTypeOptions(),
+	)
+
+	w := doRequest(mw, "/", nil)
+
+	require.Equal(t, "default-src 'self'; frame-ancestors 'self'", w.Header().Get("Content-Security-Policy"))
+	require.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+	require.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	require.Equal(t, "camera=(), geolocation=()", w.Header().Get("Permissions-Policy"))
+	require.Equal(t, "same-origin", w.Header().Get("Cross-Origin-Opener-Policy"))
+	require.Equal(t, "require-corp", w.Header().Get("Cross-Origin-Embedder-Policy"))
+	require.Equal(t, "same-site", w.Header().Get("Cross-Origin-Resource-Policy"))
+	require.NotContains(t, w.Header(), "X-Content-Type-Options")
+}
+
+func TestReportOnly(t *testing.T) {
+	mw := securityheaders.New(
+		securityheaders.WithContentSecurityPolicyReportOnly("default-src 'self'; frame-ancestors 'none'; report-to csp"),
+		securityheaders.WithCrossOriginOpenerPolicyReportOnly("same-origin"),
+		securityheaders.WithCrossOriginEmbedderPolicyReportOnly("require-corp"),
+	)
+
+	w := doRequest(mw, "/", nil)
+
+	require.NotContains(t, w.Header(), "Content-Security-Policy")
+	require.Equal(t, "default-src 'self'; frame-ancestors 'none'; report-to csp", w.Header().Get("Content-Security-Policy-Report-Only"))
+	require.Equal(t, "same-origin", w.Header().Get("Cross-Origin-Opener-Policy-Report-Only"))
+	require.Equal(t, "require-corp", w.Header().Get("Cross-Origin-Embedder-Policy-Report-Only"))
+}
+
+func TestNonce(t *testing.T) {
+	mw := securityheaders.New(
+		securityheaders.WithContentSecurityPolicy("script-src 'self' "+securityheaders.NoncePlaceholder),
+		securityheaders.WithFrameOptions(""),
+	)
+
+	var nonces []string
+
+	endpoint := func(w http.ResponseWriter, r *http.Request) {
+		nonce, ok := securityheaders.NonceFromContext(r.Context())
+		require.True(t, ok)
+
+		nonces = append(nonces, nonce)
+	}
+
+	first := doRequest(mw, "/", endpoint)
+	second := doRequest(mw, "/", endpoint)
+
+	require.Len(t, nonces, 2)
+	require.NotEqual(t, nonces[0], nonces[1])
+	require.Regexp(t, regexp.MustCompile(`^[A-Za-z0-9+/]{22}==$`), nonces[0])
+
+	require.Equal(t, "script-src 'self' 'nonce-"+nonces[0]+"'", first.Header().Get("Content-Security-Policy"))
+	require.Equal(t, "script-src 'self' 'nonce-"+nonces[1]+"'", second.Header().Get("Content-Security-Policy"))
+}
+
+func TestNonce_NotUsed(t *testing.T) {
+	doRequest(securityheaders.New(), "/", func(w http.ResponseWriter, r *http.Request) {
+		_, ok := securityheaders.NonceFromContext(r.Context())
+		require.False(t, ok)
+	})
+}
+
+func TestPerRoutePolicies(t *testing.T) {
+	mw := securityheaders.New().
+		SetPolicyMatching(route.PathPrefix("/docs"), securityheaders.Policy{
+			ContentSecurityPolicy: "default-src 'self'",
+			FrameOptions:          "SAMEORIGIN",
+		})
+
+	docs := doRequest(mw, "/docs/index.html", nil)
+	require.Equal(t, "default-src 'self'; frame-ancestors 'self'", docs.Header().Get("Content-Security-Policy"))
+	require.Equal(t, "SAMEORIGIN", docs.Header().Get("X-Frame-Options"))
+	require.NotContains(t, docs.Header(), "X-Content-Type-Options")
+
+	api := doRequest(mw, "/nodes", nil)
+	require.Equal(t, "DENY", api.Header().Get("X-Frame-Options"))
+	require.Equal(t, "nosniff", api.Header().Get("X-Content-Type-Options"))
+}
@@ -0,0 +1,82 @@
+package securityheaders
+
+type Option func(*Middleware)
+
+// WithPolicy replaces the policy, which by default is Default.
+func WithPolicy(p Policy) Option {
+	return func(m *Middleware) {
+		m.policy = p
+	}
+}
+
+// WithContentSecurityPolicy sets the policy, where NoncePlaceholder is replaced
+// by a per-request nonce, e.g. "script-src 'self' {nonce}".
+func WithContentSecurityPolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.ContentSecurityPolicy = policy
+	}
+}
+
+// WithContentSecurityPolicyReportOnly sets a policy which is only reported, not
+// enforced, by the browser. It may be combined with an enforced policy.
+func WithContentSecurityPolicyReportOnly(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.ContentSecurityPolicyReportOnly = policy
+	}
+}
+
+// WithoutContentTypeOptions omits X-Content-Type-Options.
+func WithoutContentTypeOptions() Option {
+	return func(m *Middleware) {
+		m.policy.ContentTypeOptions = ""
+	}
+}
+
+// WithFrameOptions sets X-Frame-Options to "DENY", "SAMEORIGIN" or, if empty, omits it.
+func WithFrameOptions(frameOptions string) Option {
+	return func(m *Middleware) {
+		m.policy.FrameOptions = frameOptions
+	}
+}
+
+func WithReferrerPolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.ReferrerPolicy = policy
+	}
+}
+
+func WithPermissionsPolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.PermissionsPolicy = policy
+	}
+}
+
+func WithCrossOriginOpenerPolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.CrossOriginOpenerPolicy = policy
+	}
+}
+
+func WithCrossOriginOpenerPolicyReportOnly(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.CrossOriginOpenerPolicyReportOnly = policy
+	}
+}
+
+func WithCrossOriginEmbedderPolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.CrossOriginEmbedderPolicy = policy
+	}
+}
+
+func WithCrossOriginEmbedderPolicyReportOnly(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.CrossOriginEmbedderPolicyReportOnly = policy
+	}
+}
+
+func WithCrossOriginResourcePolicy(policy string) Option {
+	return func(m *Middleware) {
+		m.policy.CrossOriginResourcePolicy = policy
+	}
+}
@@ -0,0 +1,93 @@
+package securityheaders
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NoncePlaceholder is replaced by a per-request nonce, e.g. "'nonce-ZmFrZQ=='",
+// in Content-Security-Policy values. The nonce is available through NonceFromContext.
+const NoncePlaceholder = "{nonce}"
+
+// Policy contains the values of the security headers, empty values are not sent.
+type Policy struct {
+	ContentSecurityPolicy           string
+	ContentSecurityPolicyReportOnly string
+
+	// ContentTypeOptions is the value of X-Content-Type-Options, i.e. "nosniff".
+	ContentTypeOptions string
+
+	// FrameOptions is the value of X-Frame-Options, "DENY" or "SAMEORIGIN". The
+	// equivalent frame-ancestors directive is added to Content-Security-Policy
+	// unless the policy already contains it.
+	FrameOptions string
+
+	ReferrerPolicy    string
+	PermissionsPolicy string
+
+	CrossOriginOpenerPolicy             string
+	CrossOriginOpenerPolicyReportOnly   string
+	CrossOriginEmbedderPolicy           string
+	CrossOriginEmbedderPolicyReportOnly string
+	CrossOriginResourcePolicy           string
+}
+
+// Default is the policy used without any Options.
+var Default = Policy{
+	ContentTypeOptions: "nosniff",
+	FrameOptions:       "DENY",
+	ReferrerPolicy:     "strict-origin-when-cross-origin",
+}
+
+// needsNonce returns if any of the Content-Security-Policy values uses a nonce.
+func (p Policy) needsNonce() bool {
+	return strings.Contains(p.ContentSecurityPolicy, NoncePlaceholder) ||
+		strings.Contains(p.ContentSecurityPolicyReportOnly, NoncePlaceholder)
+}
+
+func (p Policy) write(header http.Header, nonce string) {
+	set := func(key, value string) {
+		if value != "" {
+			header.Set(key, value)
+		}
+	}
+
+	set("Content-Security-Policy", p.contentSecurityPolicy(p.ContentSecurityPolicy, nonce))
+	set("Content-Security-Policy-Report-Only", p.contentSecurityPolicy(p.ContentSecurityPolicyReportOnly, nonce))
+	set("X-Content-Type-Options", p.ContentTypeOptions)
+	set("X-Frame-Options", p.FrameOptions)
+	set("Referrer-Policy", p.ReferrerPolicy)
+	set("Permissions-Policy", p.PermissionsPolicy)
+	set("Cross-Origin-Opener-Policy", p.CrossOriginOpenerPolicy)
+	set("Cross-Origin-Opener-Policy-Report-Only", p.CrossOriginOpenerPolicyReportOnly)
+	set("Cross-Origin-Embedder-Policy", p.CrossOriginEmbedderPolicy)
+	set("Cross-Origin-Embedder-Policy-Report-Only", p.CrossOriginEmbedderPolicyReportOnly)
+	set("Cross-Origin-Resource-Policy", p.CrossOriginResourcePolicy)
+}
+
+func (p Policy) contentSecurityPolicy(value, nonce string) string {
+	if value == "" {
+		return ""
+	}
+
+	if nonce != "" {
+		value = strings.ReplaceAll(value, NoncePlaceholder, "'nonce-"+nonce+"'")
+	}
+
+	if ancestors := frameAncestors(p.FrameOptions); ancestors != "" && !strings.Contains(value, "frame-ancestors") {
+		value = strings.TrimRight(strings.TrimSpace(value), ";") + "; frame-ancestors " + ancestors
+	}
+
+	return value
+}
+
+func frameAncestors(frameOptions string) string {
+	switch strings.ToUpper(frameOptions) {
+	case "DENY":
+		return "'none'"
+	case "SAMEORIGIN":
+		return "'self'"
+	}
+
+	return ""
+}
@@ -33,6 +33,11 @@ func New(opts ...Option) *Middleware {
 	return mw
 }
 
+// Stage positions the middleware in a middleware.Chain.
+func (m *Middleware) Stage() middleware.Stage {
+	return middleware.StageSpanDecorator
+}
+
 func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +51,7 @@ func (m *Middleware) Middleware() func(http.Handler) http.Handler {
 			if m.withBody && !emptyBody(r) {
 				partialBody, err := extractPartialBody(r, maxTagValueSize)
 				if err != nil {
+					middleware.RecordProblem(span, err)
 					problems.WriteResponse(ctx, err, w, r)
 					return
 				}
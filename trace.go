@@ -6,11 +6,40 @@ type (
 	Tracer = trace.Tracer
 	Span   = trace.Span
 
+	ErrorRecorder = trace.ErrorRecorder
+
+	StatusCode = trace.StatusCode
+
+	MultiTracer         = trace.MultiTracer
+	OpenTelemetryTracer = trace.OpenTelemetryTracer
+
 	// Legacy, reexported for backwards compatibility
 	OpenCensusTracer = trace.OpenCensusTracer
 	NilSpan          = trace.NilSpan
 )
 
+const (
+	StatusUnset = trace.StatusUnset
+	StatusOK    = trace.StatusOK
+	StatusError = trace.StatusError
+
+	ProblemTypeKey   = trace.ProblemTypeKey
+	ProblemStatusKey = trace.ProblemStatusKey
+)
+
+// RecordProblem marks the span as failed with the problem type and status err is rendered as.
+var RecordProblem = trace.RecordProblem
+
+// DefaultTracer is the Tracer of all middlewares created after it is set.
+// OpenTelemetry is opt-in, as services using an OpenTelemetry bridge already
+// get their spans through Datadog or OpenCensus. It is added by setting:
+//
+//	middleware.DefaultTracer = middleware.MultiTracer{
+//		middleware.DefaultTracer,
+//		new(middleware.OpenTelemetryTracer),
+//	}
+//
+// or by setting the Tracer field of a single middleware.
 var DefaultTracer Tracer = trace.MultiTracer{
 	new(trace.DatadogTracer),
 	new(trace.OpenCensusTracer),